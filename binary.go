@@ -0,0 +1,217 @@
+package fmtel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Size of an encoded ForzaPacket in bytes.
+const PacketSize = 331
+
+var ErrShortPacket = errors.New("packet too short")
+
+var le = binary.LittleEndian
+
+// Decodes a little-endian packet without reflection or allocations.
+// Bytes beyond PacketSize are ignored.
+func (m *ForzaPacket) UnmarshalBinary(b []byte) error {
+	if len(b) < PacketSize {
+		return fmt.Errorf("%w: got %d bytes, need %d", ErrShortPacket, len(b), PacketSize)
+	}
+	_ = b[PacketSize-1]
+	m.IsRaceOn = int32(le.Uint32(b[0:]))
+	m.TimestampMS = le.Uint32(b[4:])
+	m.EngineMaxRpm = math.Float32frombits(le.Uint32(b[8:]))
+	m.EngineIdleRpm = math.Float32frombits(le.Uint32(b[12:]))
+	m.CurrentEngineRpm = math.Float32frombits(le.Uint32(b[16:]))
+	m.AccelerationX = math.Float32frombits(le.Uint32(b[20:]))
+	m.AccelerationY = math.Float32frombits(le.Uint32(b[24:]))
+	m.AccelerationZ = math.Float32frombits(le.Uint32(b[28:]))
+	m.VelocityX = math.Float32frombits(le.Uint32(b[32:]))
+	m.VelocityY = math.Float32frombits(le.Uint32(b[36:]))
+	m.VelocityZ = math.Float32frombits(le.Uint32(b[40:]))
+	m.AngularVelocityX = math.Float32frombits(le.Uint32(b[44:]))
+	m.AngularVelocityY = math.Float32frombits(le.Uint32(b[48:]))
+	m.AngularVelocityZ = math.Float32frombits(le.Uint32(b[52:]))
+	m.Yaw = math.Float32frombits(le.Uint32(b[56:]))
+	m.Pitch = math.Float32frombits(le.Uint32(b[60:]))
+	m.Roll = math.Float32frombits(le.Uint32(b[64:]))
+	m.NormalizedSuspensionTravelFrontLeft = math.Float32frombits(le.Uint32(b[68:]))
+	m.NormalizedSuspensionTravelFrontRight = math.Float32frombits(le.Uint32(b[72:]))
+	m.NormalizedSuspensionTravelRearLeft = math.Float32frombits(le.Uint32(b[76:]))
+	m.NormalizedSuspensionTravelRearRight = math.Float32frombits(le.Uint32(b[80:]))
+	m.TireSlipRatioFrontLeft = math.Float32frombits(le.Uint32(b[84:]))
+	m.TireSlipRatioFrontRight = math.Float32frombits(le.Uint32(b[88:]))
+	m.TireSlipRatioRearLeft = math.Float32frombits(le.Uint32(b[92:]))
+	m.TireSlipRatioRearRight = math.Float32frombits(le.Uint32(b[96:]))
+	m.WheelRotationSpeedFrontLeft = math.Float32frombits(le.Uint32(b[100:]))
+	m.WheelRotationSpeedFrontRight = math.Float32frombits(le.Uint32(b[104:]))
+	m.WheelRotationSpeedRearLeft = math.Float32frombits(le.Uint32(b[108:]))
+	m.WheelRotationSpeedRearRight = math.Float32frombits(le.Uint32(b[112:]))
+	m.WheelOnRumbleStripFrontLeft = int32(le.Uint32(b[116:]))
+	m.WheelOnRumbleStripFrontRight = int32(le.Uint32(b[120:]))
+	m.WheelOnRumbleStripRearLeft = int32(le.Uint32(b[124:]))
+	m.WheelOnRumbleStripRearRight = int32(le.Uint32(b[128:]))
+	m.WheelInPuddleDepthFrontLeft = math.Float32frombits(le.Uint32(b[132:]))
+	m.WheelInPuddleDepthFrontRight = math.Float32frombits(le.Uint32(b[136:]))
+	m.WheelInPuddleDepthRearLeft = math.Float32frombits(le.Uint32(b[140:]))
+	m.WheelInPuddleDepthRearRight = math.Float32frombits(le.Uint32(b[144:]))
+	m.SurfaceRumbleFrontLeft = math.Float32frombits(le.Uint32(b[148:]))
+	m.SurfaceRumbleFrontRight = math.Float32frombits(le.Uint32(b[152:]))
+	m.SurfaceRumbleRearLeft = math.Float32frombits(le.Uint32(b[156:]))
+	m.SurfaceRumbleRearRight = math.Float32frombits(le.Uint32(b[160:]))
+	m.TireSlipAngleFrontLeft = math.Float32frombits(le.Uint32(b[164:]))
+	m.TireSlipAngleFrontRight = math.Float32frombits(le.Uint32(b[168:]))
+	m.TireSlipAngleRearLeft = math.Float32frombits(le.Uint32(b[172:]))
+	m.TireSlipAngleRearRight = math.Float32frombits(le.Uint32(b[176:]))
+	m.TireCombinedSlipFrontLeft = math.Float32frombits(le.Uint32(b[180:]))
+	m.TireCombinedSlipFrontRight = math.Float32frombits(le.Uint32(b[184:]))
+	m.TireCombinedSlipRearLeft = math.Float32frombits(le.Uint32(b[188:]))
+	m.TireCombinedSlipRearRight = math.Float32frombits(le.Uint32(b[192:]))
+	m.SuspensionTravelMetersFrontLeft = math.Float32frombits(le.Uint32(b[196:]))
+	m.SuspensionTravelMetersFrontRight = math.Float32frombits(le.Uint32(b[200:]))
+	m.SuspensionTravelMetersRearLeft = math.Float32frombits(le.Uint32(b[204:]))
+	m.SuspensionTravelMetersRearRight = math.Float32frombits(le.Uint32(b[208:]))
+	m.CarOrdinal = int32(le.Uint32(b[212:]))
+	m.CarClass = int32(le.Uint32(b[216:]))
+	m.CarPerformanceIndex = int32(le.Uint32(b[220:]))
+	m.DrivetrainType = int32(le.Uint32(b[224:]))
+	m.NumCylinders = int32(le.Uint32(b[228:]))
+	m.PositionX = math.Float32frombits(le.Uint32(b[232:]))
+	m.PositionY = math.Float32frombits(le.Uint32(b[236:]))
+	m.PositionZ = math.Float32frombits(le.Uint32(b[240:]))
+	m.Speed = math.Float32frombits(le.Uint32(b[244:]))
+	m.Power = math.Float32frombits(le.Uint32(b[248:]))
+	m.Torque = math.Float32frombits(le.Uint32(b[252:]))
+	m.TireTempFrontLeft = math.Float32frombits(le.Uint32(b[256:]))
+	m.TireTempFrontRight = math.Float32frombits(le.Uint32(b[260:]))
+	m.TireTempRearLeft = math.Float32frombits(le.Uint32(b[264:]))
+	m.TireTempRearRight = math.Float32frombits(le.Uint32(b[268:]))
+	m.Boost = math.Float32frombits(le.Uint32(b[272:]))
+	m.Fuel = math.Float32frombits(le.Uint32(b[276:]))
+	m.DistanceTraveled = math.Float32frombits(le.Uint32(b[280:]))
+	m.BestLap = math.Float32frombits(le.Uint32(b[284:]))
+	m.LastLap = math.Float32frombits(le.Uint32(b[288:]))
+	m.CurrentLap = math.Float32frombits(le.Uint32(b[292:]))
+	m.CurrentRaceTime = math.Float32frombits(le.Uint32(b[296:]))
+	m.LapNumber = le.Uint16(b[300:])
+	m.RacePosition = b[302]
+	m.Accel = b[303]
+	m.Brake = b[304]
+	m.Clutch = b[305]
+	m.HandBrake = b[306]
+	m.Gear = b[307]
+	m.Steer = int8(b[308])
+	m.NormalizedDrivingLine = int8(b[309])
+	m.NormalizedAIBrakeDifference = int8(b[310])
+	m.TireWearFrontLeft = math.Float32frombits(le.Uint32(b[311:]))
+	m.TireWearFrontRight = math.Float32frombits(le.Uint32(b[315:]))
+	m.TireWearRearLeft = math.Float32frombits(le.Uint32(b[319:]))
+	m.TireWearRearRight = math.Float32frombits(le.Uint32(b[323:]))
+	m.TrackOrdinal = int32(le.Uint32(b[327:]))
+	return nil
+}
+
+// Encodes the packet in the layout the game sends.
+func (m *ForzaPacket) MarshalBinary() ([]byte, error) {
+	b := make([]byte, PacketSize)
+	m.PutBinary(b)
+	return b, nil
+}
+
+// Encodes the packet into b, which must be at least PacketSize bytes long.
+func (m *ForzaPacket) PutBinary(b []byte) {
+	_ = b[PacketSize-1]
+	le.PutUint32(b[0:], uint32(m.IsRaceOn))
+	le.PutUint32(b[4:], m.TimestampMS)
+	le.PutUint32(b[8:], math.Float32bits(m.EngineMaxRpm))
+	le.PutUint32(b[12:], math.Float32bits(m.EngineIdleRpm))
+	le.PutUint32(b[16:], math.Float32bits(m.CurrentEngineRpm))
+	le.PutUint32(b[20:], math.Float32bits(m.AccelerationX))
+	le.PutUint32(b[24:], math.Float32bits(m.AccelerationY))
+	le.PutUint32(b[28:], math.Float32bits(m.AccelerationZ))
+	le.PutUint32(b[32:], math.Float32bits(m.VelocityX))
+	le.PutUint32(b[36:], math.Float32bits(m.VelocityY))
+	le.PutUint32(b[40:], math.Float32bits(m.VelocityZ))
+	le.PutUint32(b[44:], math.Float32bits(m.AngularVelocityX))
+	le.PutUint32(b[48:], math.Float32bits(m.AngularVelocityY))
+	le.PutUint32(b[52:], math.Float32bits(m.AngularVelocityZ))
+	le.PutUint32(b[56:], math.Float32bits(m.Yaw))
+	le.PutUint32(b[60:], math.Float32bits(m.Pitch))
+	le.PutUint32(b[64:], math.Float32bits(m.Roll))
+	le.PutUint32(b[68:], math.Float32bits(m.NormalizedSuspensionTravelFrontLeft))
+	le.PutUint32(b[72:], math.Float32bits(m.NormalizedSuspensionTravelFrontRight))
+	le.PutUint32(b[76:], math.Float32bits(m.NormalizedSuspensionTravelRearLeft))
+	le.PutUint32(b[80:], math.Float32bits(m.NormalizedSuspensionTravelRearRight))
+	le.PutUint32(b[84:], math.Float32bits(m.TireSlipRatioFrontLeft))
+	le.PutUint32(b[88:], math.Float32bits(m.TireSlipRatioFrontRight))
+	le.PutUint32(b[92:], math.Float32bits(m.TireSlipRatioRearLeft))
+	le.PutUint32(b[96:], math.Float32bits(m.TireSlipRatioRearRight))
+	le.PutUint32(b[100:], math.Float32bits(m.WheelRotationSpeedFrontLeft))
+	le.PutUint32(b[104:], math.Float32bits(m.WheelRotationSpeedFrontRight))
+	le.PutUint32(b[108:], math.Float32bits(m.WheelRotationSpeedRearLeft))
+	le.PutUint32(b[112:], math.Float32bits(m.WheelRotationSpeedRearRight))
+	le.PutUint32(b[116:], uint32(m.WheelOnRumbleStripFrontLeft))
+	le.PutUint32(b[120:], uint32(m.WheelOnRumbleStripFrontRight))
+	le.PutUint32(b[124:], uint32(m.WheelOnRumbleStripRearLeft))
+	le.PutUint32(b[128:], uint32(m.WheelOnRumbleStripRearRight))
+	le.PutUint32(b[132:], math.Float32bits(m.WheelInPuddleDepthFrontLeft))
+	le.PutUint32(b[136:], math.Float32bits(m.WheelInPuddleDepthFrontRight))
+	le.PutUint32(b[140:], math.Float32bits(m.WheelInPuddleDepthRearLeft))
+	le.PutUint32(b[144:], math.Float32bits(m.WheelInPuddleDepthRearRight))
+	le.PutUint32(b[148:], math.Float32bits(m.SurfaceRumbleFrontLeft))
+	le.PutUint32(b[152:], math.Float32bits(m.SurfaceRumbleFrontRight))
+	le.PutUint32(b[156:], math.Float32bits(m.SurfaceRumbleRearLeft))
+	le.PutUint32(b[160:], math.Float32bits(m.SurfaceRumbleRearRight))
+	le.PutUint32(b[164:], math.Float32bits(m.TireSlipAngleFrontLeft))
+	le.PutUint32(b[168:], math.Float32bits(m.TireSlipAngleFrontRight))
+	le.PutUint32(b[172:], math.Float32bits(m.TireSlipAngleRearLeft))
+	le.PutUint32(b[176:], math.Float32bits(m.TireSlipAngleRearRight))
+	le.PutUint32(b[180:], math.Float32bits(m.TireCombinedSlipFrontLeft))
+	le.PutUint32(b[184:], math.Float32bits(m.TireCombinedSlipFrontRight))
+	le.PutUint32(b[188:], math.Float32bits(m.TireCombinedSlipRearLeft))
+	le.PutUint32(b[192:], math.Float32bits(m.TireCombinedSlipRearRight))
+	le.PutUint32(b[196:], math.Float32bits(m.SuspensionTravelMetersFrontLeft))
+	le.PutUint32(b[200:], math.Float32bits(m.SuspensionTravelMetersFrontRight))
+	le.PutUint32(b[204:], math.Float32bits(m.SuspensionTravelMetersRearLeft))
+	le.PutUint32(b[208:], math.Float32bits(m.SuspensionTravelMetersRearRight))
+	le.PutUint32(b[212:], uint32(m.CarOrdinal))
+	le.PutUint32(b[216:], uint32(m.CarClass))
+	le.PutUint32(b[220:], uint32(m.CarPerformanceIndex))
+	le.PutUint32(b[224:], uint32(m.DrivetrainType))
+	le.PutUint32(b[228:], uint32(m.NumCylinders))
+	le.PutUint32(b[232:], math.Float32bits(m.PositionX))
+	le.PutUint32(b[236:], math.Float32bits(m.PositionY))
+	le.PutUint32(b[240:], math.Float32bits(m.PositionZ))
+	le.PutUint32(b[244:], math.Float32bits(m.Speed))
+	le.PutUint32(b[248:], math.Float32bits(m.Power))
+	le.PutUint32(b[252:], math.Float32bits(m.Torque))
+	le.PutUint32(b[256:], math.Float32bits(m.TireTempFrontLeft))
+	le.PutUint32(b[260:], math.Float32bits(m.TireTempFrontRight))
+	le.PutUint32(b[264:], math.Float32bits(m.TireTempRearLeft))
+	le.PutUint32(b[268:], math.Float32bits(m.TireTempRearRight))
+	le.PutUint32(b[272:], math.Float32bits(m.Boost))
+	le.PutUint32(b[276:], math.Float32bits(m.Fuel))
+	le.PutUint32(b[280:], math.Float32bits(m.DistanceTraveled))
+	le.PutUint32(b[284:], math.Float32bits(m.BestLap))
+	le.PutUint32(b[288:], math.Float32bits(m.LastLap))
+	le.PutUint32(b[292:], math.Float32bits(m.CurrentLap))
+	le.PutUint32(b[296:], math.Float32bits(m.CurrentRaceTime))
+	le.PutUint16(b[300:], m.LapNumber)
+	b[302] = m.RacePosition
+	b[303] = m.Accel
+	b[304] = m.Brake
+	b[305] = m.Clutch
+	b[306] = m.HandBrake
+	b[307] = m.Gear
+	b[308] = uint8(m.Steer)
+	b[309] = uint8(m.NormalizedDrivingLine)
+	b[310] = uint8(m.NormalizedAIBrakeDifference)
+	le.PutUint32(b[311:], math.Float32bits(m.TireWearFrontLeft))
+	le.PutUint32(b[315:], math.Float32bits(m.TireWearFrontRight))
+	le.PutUint32(b[319:], math.Float32bits(m.TireWearRearLeft))
+	le.PutUint32(b[323:], math.Float32bits(m.TireWearRearRight))
+	le.PutUint32(b[327:], uint32(m.TrackOrdinal))
+}
@@ -0,0 +1,105 @@
+package fmtel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func randomDatagram() []byte {
+	b := make([]byte, PacketSize)
+	rand.New(rand.NewSource(1)).Read(b)
+	return b
+}
+
+func TestUnmarshalBinaryMatchesBinaryRead(t *testing.T) {
+	if n := binary.Size(ForzaPacket{}); n != PacketSize {
+		t.Fatalf("binary.Size(ForzaPacket{}) = %d, want %d", n, PacketSize)
+	}
+	b := randomDatagram()
+
+	var want ForzaPacket
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, &want); err != nil {
+		t.Fatal(err)
+	}
+	var got ForzaPacket
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	// Compare the encodings so NaN fields from random bytes compare equal.
+	var wantBytes, gotBytes bytes.Buffer
+	binary.Write(&wantBytes, binary.LittleEndian, &want)
+	binary.Write(&gotBytes, binary.LittleEndian, &got)
+	if !bytes.Equal(gotBytes.Bytes(), wantBytes.Bytes()) {
+		t.Fatal("UnmarshalBinary and binary.Read decoded different packets")
+	}
+}
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	b := randomDatagram()
+	var p ForzaPacket
+	if err := p.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	out, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, b) {
+		t.Fatal("MarshalBinary did not reproduce the decoded bytes")
+	}
+
+	var again ForzaPacket
+	if err := again.UnmarshalBinary(out); err != nil {
+		t.Fatal(err)
+	}
+	out2, _ := again.MarshalBinary()
+	if !bytes.Equal(out2, b) {
+		t.Fatal("round trip changed the packet")
+	}
+}
+
+func TestUnmarshalBinaryShort(t *testing.T) {
+	var p ForzaPacket
+	err := p.UnmarshalBinary(make([]byte, PacketSize-1))
+	if !errors.Is(err, ErrShortPacket) {
+		t.Fatalf("got %v, want ErrShortPacket", err)
+	}
+}
+
+func TestUnmarshalBinaryAllocs(t *testing.T) {
+	b := randomDatagram()
+	var p ForzaPacket
+	allocs := testing.AllocsPerRun(100, func() {
+		p.UnmarshalBinary(b)
+	})
+	if allocs != 0 {
+		t.Fatalf("UnmarshalBinary allocated %v times per call", allocs)
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	buf := randomDatagram()
+	var p ForzaPacket
+	b.ReportAllocs()
+	b.SetBytes(PacketSize)
+	for i := 0; i < b.N; i++ {
+		if err := p.UnmarshalBinary(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBinaryRead(b *testing.B) {
+	buf := randomDatagram()
+	var p ForzaPacket
+	b.ReportAllocs()
+	b.SetBytes(PacketSize)
+	for i := 0; i < b.N; i++ {
+		if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,10 +1,20 @@
 package cars
 
 import (
+	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"sort"
+
+	"github.com/stelmanjones/fmtel/units"
 )
 
+//go:embed cars.json
+var embedded []byte
+
 var DefaultCar = Car{
 	Maker:      "Unknown",
 	Model:      "Unknown",
@@ -15,12 +25,29 @@ var DefaultCar = Car{
 }
 
 type Car struct {
-	Group      string `json:"group"`
-	Maker      string `json:"maker"`
-	Model      string `json:"model"`
+	Group      string `json:"group,omitempty"`
+	Maker      string `json:"maker,omitempty"`
+	Model      string `json:"model,omitempty"`
 	CarOrdinal int32  `json:"car_ordinal"`
-	Year       int32  `json:"year"`
-	Weight     int32  `json:"weight"`
+	Year       int32  `json:"year,omitempty"`
+	Weight     int32  `json:"weight,omitempty"`
+	// Stock performance class (D, C, B, A, S, R, P, X) and index.
+	Class string `json:"class,omitempty"`
+	PI    int32  `json:"pi,omitempty"`
+	// Stock drivetrain.
+	Drivetrain units.Drivetrain `json:"drivetrain,omitempty"`
+	// Engine layout such as "I4", "V8" or "Flat-6".
+	EngineLayout string `json:"engine_layout,omitempty"`
+	// Stock number of forward gears.
+	Gears int32 `json:"gears,omitempty"`
+	// Observed by the catalog. The tune fields are those of the car last
+	// driven, which may be upgraded; the rpm range covers every tune seen.
+	TuneClass      string           `json:"tune_class,omitempty"`
+	TunePI         int32            `json:"tune_pi,omitempty"`
+	TuneDrivetrain units.Drivetrain `json:"tune_drivetrain,omitempty"`
+	Cylinders      int32            `json:"cylinders,omitempty"`
+	MaxRpm         float32          `json:"max_rpm,omitempty"`
+	IdleRpm        float32          `json:"idle_rpm,omitempty"`
 }
 
 func FindCar(a []Car, x int32) int32 {
@@ -46,7 +73,7 @@ func SetCurrentCar(cars []Car, id int32) Car {
 }
 
 func ReadCarList(path string) ([]Car, error) {
-	cars := make([]Car, 700)
+	var cars []Car
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -59,3 +86,111 @@ func ReadCarList(path string) ([]Car, error) {
 
 	return cars, nil
 }
+
+// Database holds known cars indexed by ordinal.
+type Database struct {
+	cars map[int32]Car
+}
+
+// Loads the embedded car list, then merges the learned cars and the user
+// file at path over it. Missing files are not an error.
+func Load(path string) (*Database, error) {
+	db := &Database{cars: make(map[int32]Car)}
+	err := db.Merge(embedded)
+	if err != nil {
+		return nil, fmt.Errorf("embedded cars: %w", err)
+	}
+	if path == "" {
+		return db, nil
+	}
+
+	err = db.mergeFile(LearnedPath(path))
+	if err != nil {
+		return db, err
+	}
+	return db, db.mergeFile(path)
+}
+
+func (d *Database) mergeFile(path string) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = d.Merge(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Merges a JSON car list into the database. Entries for known ordinals only
+// override the attributes they set, so a user file can fix a single field.
+func (d *Database) Merge(content []byte) error {
+	var list []json.RawMessage
+	err := json.Unmarshal(content, &list)
+	if err != nil {
+		return err
+	}
+	for _, raw := range list {
+		var key struct {
+			CarOrdinal int32 `json:"car_ordinal"`
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return err
+		}
+		car := d.cars[key.CarOrdinal]
+		if err := json.Unmarshal(raw, &car); err != nil {
+			return err
+		}
+		d.cars[car.CarOrdinal] = car
+	}
+	return nil
+}
+
+// Adds or replaces a car.
+func (d *Database) Set(car Car) {
+	d.cars[car.CarOrdinal] = car
+}
+
+// Merges a single car over the database the same way a user file is merged.
+func (d *Database) Apply(car Car) error {
+	data, err := json.Marshal([]Car{car})
+	if err != nil {
+		return err
+	}
+	return d.Merge(data)
+}
+
+func (d *Database) Find(ordinal int32) (Car, bool) {
+	car, ok := d.cars[ordinal]
+	return car, ok
+}
+
+// Returns the car with the given ordinal, or DefaultCar carrying the ordinal.
+func (d *Database) Get(ordinal int32) Car {
+	car, ok := d.cars[ordinal]
+	if !ok {
+		car = DefaultCar
+		car.CarOrdinal = ordinal
+	}
+	return car
+}
+
+// Returns every car sorted by ordinal.
+func (d *Database) Cars() []Car {
+	list := make([]Car, 0, len(d.cars))
+	for _, car := range d.cars {
+		list = append(list, car)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CarOrdinal < list[j].CarOrdinal
+	})
+	return list
+}
+
+func (d *Database) Len() int {
+	return len(d.cars)
+}
@@ -0,0 +1,48 @@
+package cars
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddedList(t *testing.T) {
+	var list []Car
+	if err := json.Unmarshal(embedded, &list); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int32]bool)
+	for _, car := range list {
+		if seen[car.CarOrdinal] {
+			t.Errorf("car ordinal %d listed twice", car.CarOrdinal)
+		}
+		seen[car.CarOrdinal] = true
+		if car.Maker == "" || car.Model == "" {
+			t.Errorf("car ordinal %d has no maker or model", car.CarOrdinal)
+		}
+	}
+}
+
+func TestLoadMergesUserFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cars.json")
+	user := `[
+		{"car_ordinal": 9000, "maker": "Honda", "model": "Civic", "year": 2000},
+		{"car_ordinal": 9000, "pi": 500}
+	]`
+	if err := os.WriteFile(path, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second entry only sets the PI, so the name is kept.
+	car, ok := db.Find(9000)
+	if !ok || car.Maker != "Honda" || car.Year != 2000 || car.PI != 500 {
+		t.Fatalf("got %+v", car)
+	}
+	if got := db.Get(9001); got.CarOrdinal != 9001 || got.Maker != DefaultCar.Maker {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package cars
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+// Catalog is a local car file: either the user's car file, holding the
+// entries they named or edited, or the learned file next to it, holding the
+// cars recorded while driving. Load merges both over the built-in list.
+type Catalog struct {
+	path string
+	cars map[int32]Car
+}
+
+// Opens the catalog at path. A missing file gives an empty catalog.
+func OpenCatalog(path string) (*Catalog, error) {
+	c := &Catalog{path: path, cars: make(map[int32]Car)}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Car
+	err = json.Unmarshal(content, &list)
+	if err != nil {
+		return nil, err
+	}
+	for _, car := range list {
+		c.cars[car.CarOrdinal] = car
+	}
+	return c, nil
+}
+
+func (c *Catalog) Path() string {
+	return c.path
+}
+
+func (c *Catalog) Find(ordinal int32) (Car, bool) {
+	car, ok := c.cars[ordinal]
+	return car, ok
+}
+
+// Adds or replaces an entry.
+func (c *Catalog) Set(car Car) {
+	c.cars[car.CarOrdinal] = car
+}
+
+// Removes an entry and reports whether it existed.
+func (c *Catalog) Remove(ordinal int32) bool {
+	_, ok := c.cars[ordinal]
+	delete(c.cars, ordinal)
+	return ok
+}
+
+// Returns every entry sorted by ordinal.
+func (c *Catalog) Cars() []Car {
+	list := make([]Car, 0, len(c.cars))
+	for _, car := range c.cars {
+		list = append(list, car)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CarOrdinal < list[j].CarOrdinal
+	})
+	return list
+}
+
+// Returns the file learned cars are kept in for the user's car file at path:
+// cars.json gives cars.learned.json.
+func LearnedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".learned" + ext
+}
+
+// Records what the packet tells us about its car. The tune fields follow the
+// packet, the cylinder count is kept once known and the rpm range only widens.
+// Returns the entry and whether anything changed.
+func (c *Catalog) Learn(p *fmtel.ForzaPacket) (Car, bool) {
+	car, ok := c.cars[p.CarOrdinal]
+	if !ok {
+		car = Car{CarOrdinal: p.CarOrdinal}
+	}
+	next := car
+	if class := p.ParsedCarClass(); class != "-" {
+		next.TuneClass = class
+	}
+	if p.CarPerformanceIndex > 0 {
+		next.TunePI = p.CarPerformanceIndex
+	}
+	if d := p.ParsedDrivetrainType(); d != "-" {
+		next.TuneDrivetrain = units.Drivetrain(d)
+	}
+	if next.Cylinders == 0 {
+		next.Cylinders = p.NumCylinders
+	}
+	if p.EngineMaxRpm > next.MaxRpm {
+		next.MaxRpm = p.EngineMaxRpm
+	}
+	if p.EngineIdleRpm > 0 && (next.IdleRpm == 0 || p.EngineIdleRpm < next.IdleRpm) {
+		next.IdleRpm = p.EngineIdleRpm
+	}
+
+	if ok && next == car {
+		return car, false
+	}
+	c.cars[p.CarOrdinal] = next
+	return next, true
+}
+
+// Writes the catalog back to its file.
+func (c *Catalog) Save() error {
+	data, err := json.MarshalIndent(c.Cars(), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".cars-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
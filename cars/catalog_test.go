@@ -0,0 +1,84 @@
+package cars
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+func TestLearn(t *testing.T) {
+	c, err := OpenCatalog(filepath.Join(t.TempDir(), "cars.learned.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := fmtel.ForzaPacket{
+		CarOrdinal:          1234,
+		CarClass:            3,
+		CarPerformanceIndex: 700,
+		DrivetrainType:      1,
+		NumCylinders:        8,
+		EngineMaxRpm:        7000,
+		EngineIdleRpm:       900,
+	}
+	car, changed := c.Learn(&p)
+	if !changed {
+		t.Fatal("first packet did not change the catalog")
+	}
+	if car.PI != 0 || car.Class != "" || car.Drivetrain != "" {
+		t.Fatalf("stock fields set from a packet: %+v", car)
+	}
+	if car.TunePI != 700 || car.TuneClass != p.ParsedCarClass() || car.MaxRpm != 7000 {
+		t.Fatalf("got %+v", car)
+	}
+	if _, changed := c.Learn(&p); changed {
+		t.Fatal("same packet changed the catalog")
+	}
+
+	// An upgrade raises the rpm limit, a later tune lowers it again.
+	p.CarPerformanceIndex, p.EngineMaxRpm, p.EngineIdleRpm = 800, 8000, 1000
+	c.Learn(&p)
+	p.EngineMaxRpm, p.EngineIdleRpm = 7500, 800
+	car, _ = c.Learn(&p)
+	if car.TunePI != 800 || car.MaxRpm != 8000 || car.IdleRpm != 800 {
+		t.Fatalf("got %+v", car)
+	}
+}
+
+func TestLoadMergesUserFileOverLearned(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cars.json")
+	user := `[{"car_ordinal": 1234, "maker": "Honda", "model": "Civic", "pi": 500}]`
+	if err := os.WriteFile(path, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	learned, err := OpenCatalog(LearnedPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	learned.Learn(&fmtel.ForzaPacket{CarOrdinal: 1234, CarPerformanceIndex: 650, EngineMaxRpm: 8000})
+	if err := learned.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(learned.Path()); got != "cars.learned.json" {
+		t.Fatalf("learned file %s", got)
+	}
+
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	car := db.Get(1234)
+	if car.Maker != "Honda" || car.PI != 500 || car.TunePI != 650 || car.MaxRpm != 8000 {
+		t.Fatalf("got %+v", car)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != user {
+		t.Fatal("user file rewritten")
+	}
+}
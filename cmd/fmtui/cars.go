@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cars"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+// How often learned cars are saved at most while driving.
+const carSaveInterval = 10 * time.Second
+
+// carLearner records the cars driven in the learned car file, next to the
+// user's car file, and keeps the car database in step.
+type carLearner struct {
+	catalog *cars.Catalog
+	db      *cars.Database
+	dirty   bool
+	saved   time.Time
+}
+
+func newCarLearner(path string, db *cars.Database) *carLearner {
+	l := &carLearner{db: db}
+	if path == "" || db == nil {
+		return l
+	}
+	catalog, err := cars.OpenCatalog(cars.LearnedPath(path))
+	if err != nil {
+		log.Error(err)
+		return l
+	}
+	l.catalog = catalog
+	return l
+}
+
+// Records the packet's car, unless it is a known car that was never learned,
+// and saves the learned file at most every carSaveInterval.
+// Returns whether the car's entry changed.
+func (l *carLearner) update(p *fmtel.ForzaPacket) bool {
+	if l.catalog == nil || p.CarOrdinal == 0 {
+		return false
+	}
+	_, known := l.db.Find(p.CarOrdinal)
+	_, learned := l.catalog.Find(p.CarOrdinal)
+	if known && !learned {
+		return false
+	}
+
+	car, changed := l.catalog.Learn(p)
+	if changed {
+		if err := l.db.Apply(car); err != nil {
+			log.Error(err)
+		}
+		l.dirty = true
+	}
+	if l.dirty && time.Since(l.saved) >= carSaveInterval {
+		l.save()
+	}
+	return changed
+}
+
+// Saves the learned file if anything changed since the last save.
+func (l *carLearner) save() {
+	if !l.dirty {
+		return
+	}
+	l.dirty = false
+	l.saved = time.Now()
+	if err := l.catalog.Save(); err != nil {
+		log.Error(err)
+		return
+	}
+	log.Debug("Saved learned cars", "file", l.catalog.Path())
+}
+
+func carsUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: fmtui cars list [--all]")
+	fmt.Fprintln(os.Stderr, "       fmtui cars name <ordinal> <maker> <model> [year]")
+	fmt.Fprintln(os.Stderr, "       fmtui cars edit <ordinal> [flags]")
+	fmt.Fprintln(os.Stderr, "       fmtui cars remove <ordinal>")
+}
+
+// Lists and edits the local car catalog.
+func runCars(args []string) {
+	if len(args) == 0 {
+		carsUsage()
+		os.Exit(2)
+	}
+	action := args[0]
+
+	var (
+		all                        bool
+		group, maker, model, class string
+		drivetrain, layout         string
+		year, weight, pi, gears    int32
+	)
+	loader := newConfigLoader("cars "+action, args[1:], func(fs *flag.FlagSet, c *config.Config) {
+		fs.Usage = func() {
+			carsUsage()
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&c.Cars, "cars", c.Cars, "Set car catalog file.")
+		fs.BoolVar(&all, "all", false, "List built-in cars as well as the catalog.")
+		fs.StringVar(&group, "group", "", "Set car group.")
+		fs.StringVar(&maker, "maker", "", "Set car maker.")
+		fs.StringVar(&model, "model", "", "Set car model.")
+		fs.Int32Var(&year, "year", 0, "Set model year.")
+		fs.Int32Var(&weight, "weight", 0, "Set weight in kg.")
+		fs.StringVar(&class, "class", "", "Set stock performance class.")
+		fs.Int32Var(&pi, "pi", 0, "Set stock performance index.")
+		fs.StringVar(&drivetrain, "drivetrain", "", "Set stock drivetrain (FWD, RWD, AWD).")
+		fs.StringVar(&layout, "engine-layout", "", "Set engine layout.")
+		fs.Int32Var(&gears, "gears", 0, "Set stock number of forward gears.")
+	})
+	fs := loader.mustLoad()
+	file := cfg().Cars
+
+	catalog, err := cars.OpenCatalog(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	learned, err := cars.OpenCatalog(cars.LearnedPath(file))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch action {
+	case "list":
+		db, err := cars.Load(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		list := db.Cars()
+		if !all {
+			list = slices.DeleteFunc(list, func(car cars.Car) bool {
+				_, named := catalog.Find(car.CarOrdinal)
+				_, seen := learned.Find(car.CarOrdinal)
+				return !named && !seen
+			})
+		}
+		printCars(list)
+		return
+	case "name", "edit", "remove":
+	default:
+		carsUsage()
+		os.Exit(2)
+	}
+
+	ordinal, err := strconv.ParseInt(fs.Arg(0), 10, 32)
+	if err != nil {
+		log.Fatal("Invalid car ordinal", "ordinal", fs.Arg(0))
+	}
+
+	if action == "remove" {
+		named := catalog.Remove(int32(ordinal))
+		seen := learned.Remove(int32(ordinal))
+		if !named && !seen {
+			log.Fatal("Car not in catalog", "ordinal", ordinal)
+		}
+		if named {
+			if err := catalog.Save(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if seen {
+			if err := learned.Save(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	car, ok := catalog.Find(int32(ordinal))
+	if !ok {
+		car = cars.Car{CarOrdinal: int32(ordinal)}
+	}
+
+	if action == "name" {
+		if fs.NArg() < 3 {
+			carsUsage()
+			os.Exit(2)
+		}
+		car.Maker = fs.Arg(1)
+		car.Model = fs.Arg(2)
+		if fs.NArg() > 3 {
+			y, err := strconv.ParseInt(fs.Arg(3), 10, 32)
+			if err != nil {
+				log.Fatal("Invalid year", "year", fs.Arg(3))
+			}
+			car.Year = int32(y)
+		}
+	}
+
+	if fs.Changed("group") {
+		car.Group = group
+	}
+	if fs.Changed("maker") {
+		car.Maker = maker
+	}
+	if fs.Changed("model") {
+		car.Model = model
+	}
+	if fs.Changed("year") {
+		car.Year = year
+	}
+	if fs.Changed("weight") {
+		car.Weight = weight
+	}
+	if fs.Changed("class") {
+		car.Class = class
+	}
+	if fs.Changed("pi") {
+		car.PI = pi
+	}
+	if fs.Changed("drivetrain") {
+		car.Drivetrain = units.Drivetrain(drivetrain)
+	}
+	if fs.Changed("engine-layout") {
+		car.EngineLayout = layout
+	}
+	if fs.Changed("gears") {
+		car.Gears = gears
+	}
+
+	catalog.Set(car)
+	if err := catalog.Save(); err != nil {
+		log.Fatal(err)
+	}
+	printCars([]cars.Car{car})
+}
+
+func printCars(list []cars.Car) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ORDINAL\tMAKER\tMODEL\tYEAR\tCLASS\tPI\tDRIVE\tTUNE\tCYL\tIDLE\tMAX RPM")
+	for _, c := range list {
+		tune := ""
+		if c.TunePI > 0 {
+			tune = fmt.Sprintf("%s %d %s", c.TuneClass, c.TunePI, c.TuneDrivetrain)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%d\t%s\t%s\t%d\t%.0f\t%.0f\n",
+			c.CarOrdinal, c.Maker, c.Model, c.Year, c.Class, c.PI, c.Drivetrain, tune, c.Cylinders, c.IdleRpm, c.MaxRpm)
+	}
+	w.Flush()
+}
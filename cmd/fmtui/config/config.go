@@ -0,0 +1,227 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stelmanjones/fmtel/tires"
+)
+
+// Name of the config file, both in the working directory and in the XDG config directories.
+const FileName = "fmtel.toml"
+
+// Config holds every fmtui option. Flags given on the command line override the file.
+type Config struct {
+	Temperature string `toml:"temperature"`
+	UdpAddress  string `toml:"udp_address"`
+	Format      string `toml:"format"`
+	NoUi        bool   `toml:"no_ui"`
+	// Car and track list files merged over the built-in lists.
+	Cars   string `toml:"cars"`
+	Tracks string `toml:"tracks"`
+	// Directory learned track maps are kept in.
+	TrackMaps string `toml:"track_maps"`
+	// Directory dyno curves are kept in.
+	Dyno string `toml:"dyno"`
+
+	Server  Server  `toml:"server"`
+	Forward Forward `toml:"forward"`
+	Race    Race    `toml:"race"`
+	Tires   Tires   `toml:"tires"`
+	Widgets Widgets `toml:"widgets"`
+}
+
+type Server struct {
+	Address string `toml:"address"`
+	Json    bool   `toml:"json"`
+	SSE     bool   `toml:"sse"`
+	Ws      bool   `toml:"ws"`
+	Metrics bool   `toml:"metrics"`
+	Dyno    bool   `toml:"dyno"`
+	Shift   bool   `toml:"shift"`
+}
+
+// Returns true if any endpoint is enabled.
+func (s Server) Enabled() bool {
+	return s.Json || s.SSE || s.Ws || s.Metrics || s.Dyno || s.Shift
+}
+
+type Forward struct {
+	Targets  []string `toml:"targets"`
+	RaceOnly bool     `toml:"race_only"`
+	Format   string   `toml:"format"`
+}
+
+type Race struct {
+	Laps int      `toml:"laps"`
+	Time Duration `toml:"time"`
+}
+
+type Tires struct {
+	// Wear (0 to 1) the stint prediction counts down to.
+	WearLimit float32 `toml:"wear_limit"`
+	// Optimal temperature windows as "min-max" or "compound=min-max" in Temperature units.
+	Windows  []string `toml:"windows"`
+	Compound string   `toml:"compound"`
+}
+
+// Widgets toggles the TUI panels.
+type Widgets struct {
+	RaceInfo  bool `toml:"race_info"`
+	Laps      bool `toml:"laps"`
+	TireTemps bool `toml:"tire_temps"`
+	Delta     bool `toml:"delta"`
+	Fuel      bool `toml:"fuel"`
+	Tires     bool `toml:"tires"`
+	Stats     bool `toml:"stats"`
+	Pedals    bool `toml:"pedals"`
+	TrackMap  bool `toml:"track_map"`
+	GForce    bool `toml:"g_force"`
+	Dyno      bool `toml:"dyno"`
+	// Rpm bar with the shift point of the current gear.
+	ShiftLight bool `toml:"shift_light"`
+	Gearing    bool `toml:"gearing"`
+	// Draw the best lap over the track map.
+	TrackMapBest bool `toml:"track_map_best"`
+}
+
+// Duration is a time.Duration written as a string such as "1h30m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Returns the built-in defaults.
+func Default() Config {
+	return Config{
+		Temperature: "celsius",
+		UdpAddress:  ":7777",
+		Format:      "auto",
+		Cars:        "cars.json",
+		Tracks:      "tracks.json",
+		TrackMaps:   "trackmaps",
+		Dyno:        "dyno",
+		Server: Server{
+			Address: ":9999",
+		},
+		Forward: Forward{
+			Format: "auto",
+		},
+		Tires: Tires{
+			WearLimit: tires.DefaultWearLimit,
+		},
+		Widgets: Widgets{
+			RaceInfo:   true,
+			Laps:       true,
+			TireTemps:  true,
+			Delta:      true,
+			Fuel:       true,
+			Tires:      true,
+			Stats:      true,
+			Pedals:     true,
+			TrackMap:   true,
+			GForce:     true,
+			Dyno:       true,
+			ShiftLight: true,
+			Gearing:    true,
+		},
+	}
+}
+
+// Returns the directories searched for the config file, most important first:
+// the working directory, $XDG_CONFIG_HOME/fmtel and each of $XDG_CONFIG_DIRS.
+func SearchPath() []string {
+	dirs := []string{"."}
+
+	home := os.Getenv("XDG_CONFIG_HOME")
+	if home == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(h, ".config")
+		}
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, "fmtel"))
+	}
+
+	system := os.Getenv("XDG_CONFIG_DIRS")
+	if system == "" {
+		system = "/etc/xdg"
+	}
+	for _, dir := range strings.Split(system, ":") {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "fmtel"))
+		}
+	}
+	return dirs
+}
+
+// Returns the first config file found on the search path, or "" if there is none.
+func Find() string {
+	for _, dir := range SearchPath() {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Decodes the file at path over c. Options missing from the file keep their value.
+func Load(path string, c *Config) error {
+	_, err := toml.DecodeFile(path, c)
+	return err
+}
+
+// Polls the file at path and sends on the returned channel whenever its
+// modification time or size changes. The channel is closed when ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	stat := func() (time.Time, int64) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		mod, size := stat()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m, s := stat()
+				if m.Equal(mod) && s == size {
+					continue
+				}
+				mod, size = m, s
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return ch
+}
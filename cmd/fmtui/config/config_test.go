@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadKeepsMissingOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write(t, path, `
+temperature = "fahrenheit"
+
+[server]
+ws = true
+
+[race]
+time = "1h30m"
+
+[widgets]
+dyno = false
+`)
+	c := Default()
+	if err := Load(path, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Temperature != "fahrenheit" || !c.Server.Ws || c.Race.Time.Duration != 90*time.Minute || c.Widgets.Dyno {
+		t.Fatalf("options from the file not applied: %+v", c)
+	}
+	def := Default()
+	if c.UdpAddress != def.UdpAddress || c.Server.Address != def.Server.Address || !c.Widgets.Laps {
+		t.Fatalf("options missing from the file lost their default: %+v", c)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write(t, path, `[race]
+time = "soon"
+`)
+	c := Default()
+	if err := Load(path, &c); err == nil {
+		t.Fatal("invalid duration accepted")
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/home/me/.config")
+	t.Setenv("XDG_CONFIG_DIRS", "/etc/xdg:/opt/xdg")
+	want := []string{".", "/home/me/.config/fmtel", "/etc/xdg/fmtel", "/opt/xdg/fmtel"}
+	if got := SearchPath(); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CONFIG_DIRS", "")
+	t.Setenv("HOME", "/home/me")
+	want = []string{".", "/home/me/.config/fmtel", "/etc/xdg/fmtel"}
+	if got := SearchPath(); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	system := filepath.Join(dir, "system")
+	for _, d := range []string{filepath.Join(home, "fmtel"), filepath.Join(system, "fmtel"), filepath.Join(dir, "work")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", system)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "work")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if got := Find(); got != "" {
+		t.Fatalf("found %q with no config file", got)
+	}
+	write(t, filepath.Join(system, "fmtel", FileName), "")
+	if got := Find(); got != filepath.Join(system, "fmtel", FileName) {
+		t.Fatalf("got %q, want the system file", got)
+	}
+	write(t, filepath.Join(home, "fmtel", FileName), "")
+	if got := Find(); got != filepath.Join(home, "fmtel", FileName) {
+		t.Fatalf("got %q, want the user file over the system file", got)
+	}
+	write(t, FileName, "")
+	if got := Find(); got != FileName {
+		t.Fatalf("got %q, want the working directory file first", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write(t, path, `temperature = "celsius"`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Watch(ctx, path, 10*time.Millisecond)
+
+	select {
+	case <-ch:
+		t.Fatal("change reported before the file changed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A different size is seen even if the modification time has not moved on.
+	write(t, path, `temperature = "fahrenheit"`)
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not reported")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		for ok {
+			_, ok = <-ch
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/charmbracelet/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/export"
+	"github.com/stelmanjones/fmtel/recorder"
+	"github.com/stelmanjones/fmtel/server"
+)
+
+// Exports a recording, or the live stream with --live, to CSV or Parquet.
+func runExport(args []string) {
+	var (
+		output   string
+		fileType string
+		raceOnly bool
+		live     bool
+	)
+	loader := newConfigLoader("export", args, func(fs *flag.FlagSet, c *config.Config) {
+		fs.Usage = func() {
+			fmt.Fprintln(os.Stderr, "Usage: fmtui export [flags] <file>")
+			fmt.Fprintln(os.Stderr, "       fmtui export --live [flags]")
+			fs.PrintDefaults()
+		}
+		fs.StringVarP(&output, "output", "o", "", "Write to this file instead of stdout.")
+		fs.StringVar(&fileType, "type", "csv", "Set output type (csv, parquet, motec).")
+		fs.BoolVar(&raceOnly, "race-only", false, "Only export packets sent while a race is on.")
+		fs.BoolVar(&live, "live", false, "Export packets received over UDP until interrupted.")
+		fs.StringVar(&c.UdpAddress, "udp-addr", c.UdpAddress, "Set UDP connection address for --live.")
+		fs.StringVar(&c.Format, "format", c.Format, "Set packet format for --live (auto, sled, dash, horizon, motorsport).")
+	})
+	fs := loader.mustLoad()
+
+	if !live && fs.Arg(0) == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var r *recorder.Reader
+	date := time.Now()
+	if !live {
+		var err error
+		r, err = recorder.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer r.Close()
+		date = r.Header.Start
+	}
+
+	var w export.Writer
+	switch fileType {
+	case "csv":
+		w = export.NewCSVWriter(out)
+	case "parquet":
+		w = export.NewParquetWriter(out)
+	case "motec", "ld":
+		w = newMotecExport(out, date)
+	default:
+		log.Fatal("Unknown export type", "type", fileType)
+	}
+
+	var n int
+	var err error
+	if live {
+		n, err = exportLive(w, cfg().UdpAddress, decoder.FormatFromString(cfg().Format), raceOnly)
+	} else {
+		n, err = export.FromRecording(r, w, raceOnly)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Info("Exported", "rows", n)
+}
+
+func exportLive(w export.Writer, addr string, f decoder.Format, raceOnly bool) (int, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp4", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	listener := server.NewListener(conn, f)
+	listener.OnError = func(err error) {
+		log.Debug(err)
+	}
+
+	ch := make(chan decoder.Packet)
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(ctx, ch)
+	}()
+
+	log.Info("Exporting live packets", "address", addr)
+	var start time.Time
+	n := 0
+	for {
+		select {
+		case packet := <-ch:
+			if raceOnly && packet.IsRaceOn != 1 {
+				continue
+			}
+			if start.IsZero() {
+				start = time.Now()
+			}
+			s := export.Sample{Time: time.Since(start), Packet: packet}
+			if err := w.Write(&s); err != nil {
+				cancel()
+				<-done
+				return n, err
+			}
+			n++
+		case <-done:
+			return n, nil
+		}
+	}
+}
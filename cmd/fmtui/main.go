@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/muesli/termenv"
@@ -13,6 +19,7 @@ import (
 	"atomicgo.dev/keyboard/keys"
 	flag "github.com/spf13/pflag"
 	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
 	"github.com/stelmanjones/fmtel/cmd/fmtui/input"
 	"github.com/stelmanjones/fmtel/cmd/fmtui/tui"
 	"github.com/stelmanjones/fmtel/cmd/fmtui/types"
@@ -20,11 +27,45 @@ import (
 	"github.com/alexandrevicenzi/go-sse"
 	"github.com/charmbracelet/log"
 	"github.com/stelmanjones/fmtel/cars"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/delta"
+	"github.com/stelmanjones/fmtel/dyno"
+	"github.com/stelmanjones/fmtel/forward"
+	"github.com/stelmanjones/fmtel/gearing"
+	"github.com/stelmanjones/fmtel/gforce"
+	"github.com/stelmanjones/fmtel/hub"
+	"github.com/stelmanjones/fmtel/laps"
+	"github.com/stelmanjones/fmtel/metrics"
 	"github.com/stelmanjones/fmtel/server"
+	"github.com/stelmanjones/fmtel/shift"
+	"github.com/stelmanjones/fmtel/tires"
+	"github.com/stelmanjones/fmtel/trackmap"
+	"github.com/stelmanjones/fmtel/tracks"
 	"github.com/stelmanjones/fmtel/units"
+	"golang.org/x/term"
 )
 
-var Pack = fmtel.DefaultForzaPacket
+// Every new race packet is published here once.
+var telemetry = hub.New()
+
+var wsHandler = server.NewWebSocketHandler(telemetry)
+
+var stats = metrics.New(telemetry)
+
+// Power and torque curves of every car driven.
+var dynamometer = dyno.New()
+
+// Gear ratios and tire radius of the current car.
+var gearbox = gearing.NewAnalyzer()
+
+// Shift points of the current car, worked out from its dyno curve and gear ratios.
+var shiftAdvisor = shift.NewAdvisor(dynamometer, gearbox)
+
+// Minimum interval between SSE messages.
+const sseInterval = 200 * time.Millisecond
+
+// How often the config file is checked for changes.
+const configPollInterval = time.Second
 
 type App struct {
 	Settings   Settings
@@ -37,19 +78,15 @@ type Settings struct {
 	UdpAddress  string
 }
 
-// HACK: Move these to the settings struct?
-var (
-	temp       string
-	udpAddress string
-	enableJson bool
-	enableSSE  bool
-	baseUrl    string
-	noUi       bool
-)
-
 // TODO: Rename this function.
 func responder(w http.ResponseWriter, r *http.Request) {
-	data, err := Pack.ToJson()
+	stats.JsonRequests.Add(1)
+	packet, ok := telemetry.Latest()
+	data, err := packet.ToJson()
+	if !ok {
+		// Nothing received yet, so answer with every field zeroed as before.
+		data, err = packet.ForzaPacket.ToJson()
+	}
 	if err != nil {
 		log.Error(err)
 	}
@@ -65,59 +102,243 @@ func responder(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func serveHTTP(address string) {
-	if enableSSE {
-		s := sse.NewServer(&sse.Options{
-			Headers: map[string]string{
-				"Access-Control-Allow-Origin": "*",
-			},
-		})
-		defer s.Shutdown()
-		http.Handle("/sse", s)
+// Wraps h so it only answers while enabled returns true for the current config.
+func endpoint(enabled func(s config.Server) bool, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !enabled(cfg().Server) {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
 
-		// HACK: There is probably a better way to do this loop.
-		go func() {
-			ticker := time.Tick(200 * time.Millisecond)
-			for {
-				data, err := Pack.ToJson()
-				if err != nil {
-					log.Error(err)
+// httpServer serves the telemetry endpoints and follows config changes.
+type httpServer struct {
+	mux  *http.ServeMux
+	srv  *http.Server
+	addr string
+	sse  *sse.Server
+}
+
+func newHTTPServer() *httpServer {
+	mux := http.NewServeMux()
+
+	s := sse.NewServer(&sse.Options{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	})
+	mux.Handle("/sse", endpoint(func(c config.Server) bool { return c.SSE }, s))
+	stats.AddClientGauge("sse", s.ClientCount)
+
+	go func() {
+		sub := telemetry.Subscribe(1, hub.DropOldest)
+		defer sub.Close()
+		// The subscription holds only the newest packet, which is sent on every tick.
+		ticker := time.NewTicker(sseInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			var packet decoder.Packet
+			select {
+			case p, ok := <-sub.C:
+				if !ok {
 					return
 				}
+				packet = p
+			default:
+				continue
+			}
+			if s.ClientCount() == 0 {
+				continue
+			}
 
-				<-ticker
-				if s.ClientCount() == 0 {
-					log.Debug("No clients connected")
-					time.Sleep(1 * time.Second)
-					continue
-				}
-				s.SendMessage("/sse", sse.SimpleMessage(string(data)))
+			data, err := packet.ToJson()
+			if err != nil {
+				log.Error(err)
+				continue
 			}
-		}()
+			s.SendMessage("/sse", sse.SimpleMessage(string(data)))
+		}
+	}()
+
+	mux.Handle("/json", endpoint(func(c config.Server) bool { return c.Json }, http.HandlerFunc(responder)))
+	mux.Handle("/ws", endpoint(func(c config.Server) bool { return c.Ws }, wsHandler))
+	stats.AddClientGauge("ws", wsHandler.ClientCount)
+	mux.Handle("/metrics", endpoint(func(c config.Server) bool { return c.Metrics }, stats))
+	mux.Handle("/dyno", endpoint(func(c config.Server) bool { return c.Dyno }, dynamometer))
+	mux.Handle("/shift", endpoint(func(c config.Server) bool { return c.Shift }, shiftAdvisor))
+
+	return &httpServer{mux: mux, sse: s}
+}
+
+// Starts, stops or moves the server to match s.
+func (h *httpServer) apply(s config.Server) {
+	if h.srv != nil && (!s.Enabled() || s.Address != h.addr) {
+		h.srv.Close()
+		h.srv = nil
+		log.Debugf("Telemetry Server at %s stopped", h.addr)
 	}
-	if enableJson {
-		http.HandleFunc("/json", responder)
+	if h.srv != nil || !s.Enabled() {
+		return
 	}
 
-	log.Debugf("Telemetry Server started at %s", baseUrl)
-	log.Fatal(http.ListenAndServe(address, nil))
+	h.addr = s.Address
+	h.srv = &http.Server{Addr: s.Address, Handler: h.mux}
+	go func(srv *http.Server) {
+		err := srv.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err)
+		}
+	}(h.srv)
+	log.Debugf("Telemetry Server started at %s", s.Address)
+}
+
+func (h *httpServer) close() {
+	if h.srv != nil {
+		h.srv.Close()
+	}
+	h.sse.Shutdown()
 }
 
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 }
 
+// Returns the config keys that changed from old to c but are only applied on start.
+func restartKeys(old, c *config.Config) []string {
+	var changed []string
+	if c.UdpAddress != old.UdpAddress {
+		changed = append(changed, "udp_address")
+	}
+	if c.Format != old.Format {
+		changed = append(changed, "format")
+	}
+	if c.NoUi != old.NoUi {
+		changed = append(changed, "no_ui")
+	}
+	if !slices.Equal(c.Forward.Targets, old.Forward.Targets) {
+		changed = append(changed, "forward.targets")
+	}
+	if c.Forward.RaceOnly != old.Forward.RaceOnly {
+		changed = append(changed, "forward.race_only")
+	}
+	if c.Forward.Format != old.Forward.Format {
+		changed = append(changed, "forward.format")
+	}
+	return changed
+}
+
+// Produces packets on ch until ctx is cancelled.
+type packetSource func(ctx context.Context, ch chan<- decoder.Packet) error
+
 func main() {
-	flag.StringVar(&temp, "temp", "celsius", "Set temperature unit.")
-	flag.StringVar(&udpAddress, "udp-addr", ":7777", "Set UDP connection address.")
-	flag.StringVar(&baseUrl, "base-url", ":9999", "Set telemetry server address.")
-	flag.BoolVar(&enableJson, "json", false, "Enable JSON endpoint.")
-	flag.BoolVar(&enableSSE, "sse", false, "Enable SSE endpoint.")
-	flag.BoolVar(&noUi, "no-ui", false, "Run without TUI.")
-	flag.Lookup("json").NoOptDefVal = "true"
-	flag.Lookup("sse").NoOptDefVal = "true"
-	flag.Lookup("no-ui").NoOptDefVal = "true"
-	flag.Parse()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "record":
+			runRecord(os.Args[2:])
+			return
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "cars":
+			runCars(os.Args[2:])
+			return
+		}
+	}
+
+	loader := newConfigLoader("fmtui", os.Args[1:], func(fs *flag.FlagSet, c *config.Config) {
+		bindFlags(fs, c)
+		fs.StringVar(&c.UdpAddress, "udp-addr", c.UdpAddress, "Set UDP connection address.")
+		fs.BoolVar(&c.NoUi, "no-ui", c.NoUi, "Run without TUI.")
+		fs.StringVar(&c.Format, "format", c.Format, "Set packet format (auto, sled, dash, horizon, motorsport).")
+		fs.StringArrayVar(&c.Forward.Targets, "forward", c.Forward.Targets, "Forward raw datagrams to host:port (repeatable).")
+		fs.BoolVar(&c.Forward.RaceOnly, "forward-race-only", c.Forward.RaceOnly, "Only forward datagrams while a race is on.")
+		fs.StringVar(&c.Forward.Format, "forward-format", c.Forward.Format, "Convert forwarded datagrams to this format (auto, sled, dash).")
+		fs.Lookup("no-ui").NoOptDefVal = "true"
+	})
+	loader.mustLoad()
+	c := cfg()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp4", c.UdpAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer conn.Close()
+	log.Debug("Starting server!", "address", c.UdpAddress, "config", loader.path)
+
+	listener := server.NewListener(conn, decoder.FormatFromString(c.Format))
+	listener.OnError = func(err error) {
+		var decodeErr *server.DecodeError
+		if errors.As(err, &decodeErr) {
+			stats.DecodeErrors.Add(1)
+		} else {
+			stats.ReadErrors.Add(1)
+		}
+		log.Debug(err)
+	}
+
+	if len(c.Forward.Targets) > 0 {
+		forwarder, err := forward.New(c.Forward.Targets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer forwarder.Close()
+
+		forwarder.Source = listener.Format
+		forwarder.Target = decoder.FormatFromString(c.Forward.Format)
+		forwarder.OnError = func(err error) {
+			log.Debug(err)
+		}
+		if c.Forward.RaceOnly {
+			forwarder.Filter = forward.RaceOnly
+		}
+		listener.OnDatagram = func(b []byte, _ time.Time) {
+			forwarder.Forward(b)
+		}
+		log.Debug("Forwarding", "targets", c.Forward.Targets)
+	}
+
+	run(ctx, loader, listener.Listen, nil)
+}
+
+// Publishes every new packet received while a race is on.
+func publish(ctx context.Context, ch <-chan decoder.Packet) {
+	var last decoder.Packet
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case packet := <-ch:
+			stats.PacketsReceived.Add(1)
+			if !packet.IsPaused() {
+				continue
+			}
+
+			if last.TimestampMS == packet.TimestampMS {
+				stats.DuplicateDropped.Add(1)
+				continue
+			}
+
+			last = packet
+			telemetry.Publish(packet)
+		}
+	}
+}
+
+// Runs the TUI, or the headless loop with --no-ui, fed by source.
+// Keys not handled by the TUI itself are passed to onKey if set.
+// Changes to the config file are applied while running.
+func run(ctx context.Context, loader *configLoader, source packetSource, onKey func(key keys.Key)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	out := termenv.DefaultOutput()
 
@@ -125,6 +346,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	noUi := cfg().NoUi
 	if !noUi {
 		cursor.Hide()
 		out.AltScreen()
@@ -134,50 +356,124 @@ func main() {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	settings := types.Settings{
-		Temperature: units.TempFromString(temp),
-		UdpAddress:  udpAddress,
+	settings := newSettings(cfg())
+	carList, err := cars.Load(cfg().Cars)
+	if err != nil {
+		log.Error(err)
 	}
-	carList, err := cars.ReadCarList("cars.json")
+	learner := newCarLearner(cfg().Cars, carList)
+	defer func() { learner.save() }()
+	trackList, err := tracks.Load(cfg().Tracks)
 	if err != nil {
 		log.Error(err)
 	}
 	app := types.App{
-		CurrentCar: cars.DefaultCar,
-		CarList:    carList,
-		Settings:   settings,
+		CurrentCar:   carList.Get(0),
+		Cars:         carList,
+		Tracks:       trackList,
+		CurrentTrack: trackList.Get(0),
+		Settings:     settings,
+		Laps:         laps.NewTracker(),
+		Delta:        delta.NewTracker(),
+		Tires:        tires.NewTracker(),
+		TempTrend:    tires.NewTempTrend(),
+		TrackMap:     trackmap.NewTracker(),
+		GTrail:       gforce.NewTrail(),
+		Dyno:         dynamometer,
+		Gearing:      gearbox,
+		Shift:        shiftAdvisor,
 	}
-	in := make(chan keys.Key)
-	ch := make(chan fmtel.ForzaPacket)
-	if err != nil {
+	if err := app.Dyno.Load(cfg().Dyno); err != nil {
 		log.Error(err)
 	}
+	// Files are written off the packet loop. Dyno curves are saved once more
+	// on the way out.
+	saves := make(chan func(), 8)
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		for save := range saves {
+			save()
+		}
+	}()
+	defer func() {
+		close(saves)
+		<-saved
+		saveDyno(app.Dyno)
+	}()
+	in := make(chan keys.Key)
+	ch := make(chan decoder.Packet)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- source(ctx, ch)
+	}()
+	go publish(ctx, ch)
+
+	sub := telemetry.Subscribe(64, hub.DropOldest)
+	defer sub.Close()
 
 	shutdown := func() {
+		cancel()
+		<-done
 		out.ExitAltScreen()
 		restoreConsole()
-		close(in)
-		close(ch)
-		os.Exit(0)
 	}
 
-	conn, err := net.ListenPacket("udp4", settings.UdpAddress)
-	if err != nil {
-		log.Error(err)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		go input.ListenForInput(in)
 	}
+	httpd := newHTTPServer()
+	defer httpd.close()
+	httpd.apply(cfg().Server)
 
-	defer conn.Close()
-	log.Debug("Starting server!", "address", settings.UdpAddress)
-
-	go server.ReadPackets(conn, ch)
-	go input.ListenForInput(in)
-	if enableJson || enableSSE {
-		go serveHTTP(baseUrl)
+	var reload <-chan struct{}
+	if loader.path != "" {
+		reload = config.Watch(ctx, loader.path, configPollInterval)
 	}
 	out.ClearScreen()
+	var received decoder.Packet
 	var packet fmtel.ForzaPacket
 	for {
 		select {
+		case <-ctx.Done():
+			shutdown()
+			return
+		case err := <-done:
+			// The replay ended or the listener failed.
+			done <- err
+			shutdown()
+			if err != nil {
+				log.Error("Telemetry source stopped", "err", err)
+			}
+			return
+		case _, ok := <-reload:
+			if !ok {
+				reload = nil
+				continue
+			}
+			c, _, err := loader.load()
+			if err != nil {
+				log.Error("Config not reloaded", "file", loader.path, "err", err)
+				continue
+			}
+			if changed := restartKeys(cfg(), &c); len(changed) > 0 {
+				log.Warn("Restart fmtui to apply config changes", "keys", strings.Join(changed, ", "))
+			}
+			if c.Cars != cfg().Cars || c.Tracks != cfg().Tracks {
+				learner.save()
+				reloadLists(&app, &c)
+				learner = newCarLearner(c.Cars, app.Cars)
+			}
+			settings := newSettings(&c)
+			if c.Temperature == cfg().Temperature {
+				// Keep the unit toggled with Ctrl+T unless the file changed it.
+				settings.Temperature = app.Settings.Temperature
+			}
+			current.Store(&c)
+			app.Settings = settings
+			httpd.apply(c.Server)
+			log.Debug("Config reloaded", "file", loader.path)
 		case key := <-in:
 			{
 				switch key.Code {
@@ -192,36 +488,62 @@ func main() {
 						}()
 						app.Settings.Temperature = t
 					}
+				case keys.PgUp:
+					{
+						app.LapScroll = tui.ClampLapScroll(app.LapScroll+1, len(app.Laps.Laps()))
+					}
+				case keys.PgDown:
+					{
+						app.LapScroll = tui.ClampLapScroll(app.LapScroll-1, len(app.Laps.Laps()))
+					}
 				case keys.CtrlC, keys.Escape:
 					{
 						shutdown()
+						return
 					}
 				default:
 					{
+						if onKey != nil {
+							onKey(key)
+						}
 						continue
 					}
 				}
 			}
-		case packet = <-ch:
-			{
-			}
-			if !packet.IsPaused() {
-				continue
+		case received = <-sub.C:
+			packet = received.ForzaPacket
+			app.Laps.Update(&packet)
+			app.Delta.Update(&packet)
+			app.Tires.Update(&packet)
+			app.TempTrend.Update(&packet)
+			app.GTrail.Update(&packet)
+			if app.Dyno.Update(&packet) {
+				// A save already queued writes every changed curve.
+				select {
+				case saves <- func() { saveDyno(app.Dyno) }:
+				default:
+				}
 			}
+			app.Gearing.Update(&packet)
+			app.Shift.Update(&packet)
 
-			if Pack.TimestampMS == packet.TimestampMS {
-				continue
+			if learner.update(&packet) || packet.CarOrdinal != app.CurrentCar.CarOrdinal {
+				app.CurrentCar = app.Cars.Get(packet.CarOrdinal)
 			}
-
-			Pack = packet
-
-			if !noUi {
-				if cars.HasCarChanged(Pack.CarOrdinal, packet.CarOrdinal) {
-					result := cars.SetCurrentCar(app.CarList, packet.CarOrdinal)
-					app.CurrentCar = result
+			// Without a track ordinal the map would mix every track together.
+			if received.HasField("TrackOrdinal") {
+				if packet.TrackOrdinal != app.CurrentTrack.TrackOrdinal {
+					app.CurrentTrack = app.Tracks.Get(packet.TrackOrdinal)
+					loadTrackMap(app.TrackMap, packet.TrackOrdinal)
+				}
+				if app.TrackMap.Update(&packet) {
+					m, _ := app.TrackMap.Map()
+					saves <- func() { saveTrackMap(m) }
 				}
+			}
 
-				layout := tui.Render(&packet, &app)
+			if !noUi {
+				layout := tui.Render(&received, &app)
 				if err != nil {
 					log.Error(err)
 				}
@@ -229,7 +551,53 @@ func main() {
 				out.MoveCursor(0, 0)
 				out.WriteString(layout)
 			}
-
 		}
 	}
 }
+
+// Reloads the car and track lists from the files in c.
+func reloadLists(app *types.App, c *config.Config) {
+	carList, err := cars.Load(c.Cars)
+	if err != nil {
+		log.Error(err)
+	}
+	if carList != nil {
+		app.Cars = carList
+		app.CurrentCar = carList.Get(app.CurrentCar.CarOrdinal)
+	}
+	trackList, err := tracks.Load(c.Tracks)
+	if err != nil {
+		log.Error(err)
+	}
+	if trackList != nil {
+		app.Tracks = trackList
+		app.CurrentTrack = trackList.Get(app.CurrentTrack.TrackOrdinal)
+	}
+}
+
+// Loads the saved map of a track into the tracker, if there is one.
+func loadTrackMap(t *trackmap.Tracker, ordinal int32) {
+	m, err := trackmap.Load(cfg().TrackMaps, ordinal)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	t.SetMap(m)
+}
+
+func saveTrackMap(m trackmap.Map) {
+	if err := trackmap.Save(cfg().TrackMaps, m); err != nil {
+		log.Error(err)
+		return
+	}
+	log.Debug("Learned track map", "track", m.TrackOrdinal, "points", len(m.Outline))
+}
+
+func saveDyno(d *dyno.Dyno) {
+	if err := d.Save(cfg().Dyno); err != nil {
+		log.Error(err)
+	}
+}
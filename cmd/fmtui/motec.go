@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cars"
+	"github.com/stelmanjones/fmtel/export"
+	"github.com/stelmanjones/fmtel/motec"
+	"github.com/stelmanjones/fmtel/tracks"
+)
+
+// Writes exported samples as a MoTeC log, filling the session from the first packet.
+type motecExport struct {
+	w     *motec.Writer
+	out   io.Writer
+	first bool
+}
+
+func newMotecExport(out io.Writer, date time.Time) *motecExport {
+	return &motecExport{
+		w:   motec.NewWriter(motec.Session{Date: date, Event: "Forza"}),
+		out: out,
+	}
+}
+
+func (m *motecExport) Write(s *export.Sample) error {
+	m.Add(&s.Packet.ForzaPacket)
+	return nil
+}
+
+func (m *motecExport) Add(p *fmtel.ForzaPacket) {
+	if !m.first {
+		m.first = true
+		setMotecSession(&m.w.Session, p)
+	}
+	m.w.Add(p)
+}
+
+func (m *motecExport) Close() error {
+	_, err := m.w.WriteTo(m.out)
+	return err
+}
+
+func setMotecSession(s *motec.Session, p *fmtel.ForzaPacket) {
+	carList, err := cars.Load(cfg().Cars)
+	if err == nil {
+		if car, ok := carList.Find(p.CarOrdinal); ok {
+			s.Vehicle = fmt.Sprintf("%d %s %s", car.Year, car.Maker, car.Model)
+			s.VehicleType = car.Group
+			s.Weight = uint32(car.Weight)
+		}
+	}
+	trackList, err := tracks.Load(cfg().Tracks)
+	if err == nil {
+		if track, ok := trackList.Find(p.TrackOrdinal); ok {
+			s.Venue = track.Name()
+		}
+	}
+	s.Comment = fmt.Sprintf("Car %d, PI %d, track %d", p.CarOrdinal, p.CarPerformanceIndex, p.TrackOrdinal)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/charmbracelet/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/recorder"
+	"github.com/stelmanjones/fmtel/server"
+)
+
+// Records every received datagram to a file until interrupted.
+func runRecord(args []string) {
+	var compress, motecPath string
+	loader := newConfigLoader("record", args, func(fs *flag.FlagSet, c *config.Config) {
+		fs.Usage = func() {
+			fmt.Fprintln(os.Stderr, "Usage: fmtui record [flags] [file]")
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&c.UdpAddress, "udp-addr", c.UdpAddress, "Set UDP connection address.")
+		fs.StringVar(&c.Format, "format", c.Format, "Set packet format (auto, sled, dash, horizon, motorsport).")
+		fs.StringVar(&compress, "compress", "none", "Compress recording (none, gzip, zstd).")
+		fs.StringVar(&motecPath, "motec", "", "Also write race packets to this MoTeC i2 (.ld) file.")
+	})
+	fs := loader.mustLoad()
+
+	log.SetLevel(log.DebugLevel)
+
+	path := fs.Arg(0)
+	if path == "" {
+		path = fmt.Sprintf("session-%s.fmtel", time.Now().Format("20060102-150405"))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp4", cfg().UdpAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	f := decoder.FormatFromString(cfg().Format)
+	w, err := recorder.Create(path, f, recorder.CompressionFromString(compress))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var ld *motecExport
+	var ldFile *os.File
+	if motecPath != "" {
+		ldFile, err = os.Create(motecPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer ldFile.Close()
+		ld = newMotecExport(ldFile, time.Now())
+	}
+
+	listener := server.NewListener(conn, f)
+	listener.OnError = func(err error) {
+		log.Debug(err)
+	}
+	listener.OnDatagram = func(b []byte, at time.Time) {
+		err := w.Write(b, at)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	ch := make(chan decoder.Packet)
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(ctx, ch)
+	}()
+
+	log.Info("Recording", "file", path, "address", cfg().UdpAddress)
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case packet := <-ch:
+			if ld != nil && packet.IsRaceOn == 1 {
+				ld.Add(&packet.ForzaPacket)
+			}
+		case <-ticker.C:
+			log.Debug("Recording", "packets", w.Count())
+		case <-done:
+			err := w.Close()
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Info("Recording saved", "file", path, "packets", w.Count())
+			if ld != nil {
+				if err := ld.Close(); err != nil {
+					log.Fatal(err)
+				}
+				log.Info("MoTeC log saved", "file", motecPath, "samples", ld.w.Len())
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/charmbracelet/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/input"
+	"github.com/stelmanjones/fmtel/replay"
+	"golang.org/x/term"
+)
+
+const replaySkip = 10 * time.Second
+
+// Plays a recording back over UDP, or straight into the TUI with --tui.
+func runReplay(args []string) {
+	var (
+		to     string
+		speed  float64
+		loop   bool
+		seek   time.Duration
+		useTui bool
+	)
+	loader := newConfigLoader("replay", args, func(fs *flag.FlagSet, c *config.Config) {
+		fs.Usage = func() {
+			fmt.Fprintln(os.Stderr, "Usage: fmtui replay [flags] <file>")
+			fmt.Fprintln(os.Stderr, "Keys: space pause/resume, left/right seek, up/down change speed.")
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&to, "to", "127.0.0.1:7777", "Send datagrams to this UDP address.")
+		fs.Float64Var(&speed, "speed", 1, "Set playback speed multiplier.")
+		fs.BoolVar(&loop, "loop", false, "Restart playback at the end of the recording.")
+		fs.DurationVar(&seek, "seek", 0, "Start playback at this offset.")
+		fs.BoolVar(&useTui, "tui", false, "Play into the TUI instead of over UDP.")
+		bindFlags(fs, c)
+	})
+	fs := loader.mustLoad()
+
+	path := fs.Arg(0)
+	if path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	player, err := replay.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	player.SetSpeed(speed)
+	player.SetLoop(loop)
+	player.Seek(seek)
+
+	onKey := func(key keys.Key) {
+		switch key.Code {
+		case keys.Space:
+			player.TogglePause()
+		case keys.Right:
+			player.Skip(replaySkip)
+		case keys.Left:
+			player.Skip(-replaySkip)
+		case keys.Up:
+			player.SetSpeed(player.Speed() * 2)
+		case keys.Down:
+			player.SetSpeed(player.Speed() / 2)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if useTui {
+		run(ctx, loader, player.ToChannel, onKey)
+		return
+	}
+
+	conn, err := net.Dial("udp4", to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	header := player.Header()
+	log.Info("Replaying", "file", path, "to", to, "format", header.Format, "recorded", header.Start.Format(time.DateTime), "length", player.Duration().Round(time.Second))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- player.ToUDP(ctx, conn)
+	}()
+
+	in := make(chan keys.Key)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		go input.ListenForInput(in)
+	}
+	for {
+		select {
+		case key := <-in:
+			switch key.Code {
+			case keys.CtrlC, keys.Escape:
+				cancel()
+			default:
+				onKey(key)
+				log.Info("Replay", "position", player.Position().Round(time.Second), "speed", player.Speed(), "paused", player.Paused())
+			}
+		case err := <-done:
+			if err != nil && err != context.Canceled {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+}
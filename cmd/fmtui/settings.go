@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/charmbracelet/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/types"
+	"github.com/stelmanjones/fmtel/strategy"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+// The active configuration. It is replaced as a whole when the config file changes.
+var current atomic.Pointer[config.Config]
+
+func cfg() *config.Config {
+	return current.Load()
+}
+
+// Binds the options shared by the TUI commands to c.
+func bindFlags(fs *flag.FlagSet, c *config.Config) {
+	fs.StringVar(&c.Temperature, "temp", c.Temperature, "Set temperature unit.")
+	fs.StringVar(&c.Server.Address, "base-url", c.Server.Address, "Set telemetry server address.")
+	fs.BoolVar(&c.Server.Json, "json", c.Server.Json, "Enable JSON endpoint.")
+	fs.BoolVar(&c.Server.SSE, "sse", c.Server.SSE, "Enable SSE endpoint.")
+	fs.BoolVar(&c.Server.Ws, "ws", c.Server.Ws, "Enable WebSocket endpoint.")
+	fs.BoolVar(&c.Server.Metrics, "metrics", c.Server.Metrics, "Enable Prometheus metrics endpoint.")
+	fs.BoolVar(&c.Server.Dyno, "dyno", c.Server.Dyno, "Enable dyno curve endpoint.")
+	fs.BoolVar(&c.Server.Shift, "shift", c.Server.Shift, "Enable shift point endpoint.")
+	fs.StringVar(&c.Tracks, "tracks", c.Tracks, "Set track list file merged over the built-in tracks.")
+	fs.StringVar(&c.Cars, "cars", c.Cars, "Set car list file merged over the built-in cars.")
+	fs.StringVar(&c.TrackMaps, "track-maps", c.TrackMaps, "Set directory learned track maps are kept in.")
+	fs.StringVar(&c.Dyno, "dyno-dir", c.Dyno, "Set directory dyno curves are kept in.")
+	fs.BoolVar(&c.Widgets.TrackMapBest, "track-map-best", c.Widgets.TrackMapBest, "Draw the best lap over the track map.")
+	fs.IntVar(&c.Race.Laps, "race-laps", c.Race.Laps, "Set race length in laps for the fuel strategy.")
+	fs.DurationVar(&c.Race.Time.Duration, "race-time", c.Race.Time.Duration, "Set race length in time for the fuel strategy.")
+	fs.Float32Var(&c.Tires.WearLimit, "tire-wear-limit", c.Tires.WearLimit, "Set tire wear (0 to 1) to predict the stint length for.")
+	fs.StringArrayVar(&c.Tires.Windows, "tire-window", c.Tires.Windows, "Set optimal tire temperature window as min-max or compound=min-max in --temp units (repeatable).")
+	fs.StringVar(&c.Tires.Compound, "tire-compound", c.Tires.Compound, "Set the fitted compound whose --tire-window applies.")
+	fs.Lookup("json").NoOptDefVal = "true"
+	fs.Lookup("sse").NoOptDefVal = "true"
+	fs.Lookup("ws").NoOptDefVal = "true"
+	fs.Lookup("metrics").NoOptDefVal = "true"
+	fs.Lookup("dyno").NoOptDefVal = "true"
+	fs.Lookup("shift").NoOptDefVal = "true"
+	fs.Lookup("track-map-best").NoOptDefVal = "true"
+}
+
+// configLoader reads the config file and parses the command line over it,
+// so flags given on the command line always win, also after a reload.
+type configLoader struct {
+	name string
+	args []string
+	bind func(fs *flag.FlagSet, c *config.Config)
+	// Config file in use, "" if there is none.
+	path string
+}
+
+func newFlagSet(name string, path *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(path, "config", *path, "Set config file (default: "+config.FileName+" in the working directory or the XDG config directories).")
+	return fs
+}
+
+// Finds the config file from --config or the XDG search path.
+func newConfigLoader(name string, args []string, bind func(fs *flag.FlagSet, c *config.Config)) *configLoader {
+	var path string
+	scratch := config.Default()
+	fs := newFlagSet(name, &path)
+	bind(fs, &scratch)
+	fs.Parse(args)
+
+	if path == "" {
+		path = config.Find()
+	}
+	return &configLoader{name: name, args: args, bind: bind, path: path}
+}
+
+// Returns the config file with the command line applied, and the parsed flag set.
+func (l *configLoader) load() (config.Config, *flag.FlagSet, error) {
+	c := config.Default()
+	if l.path != "" {
+		if err := config.Load(l.path, &c); err != nil {
+			return c, nil, err
+		}
+	}
+
+	path := l.path
+	fs := newFlagSet(l.name, &path)
+	l.bind(fs, &c)
+	fs.Parse(l.args)
+	return c, fs, nil
+}
+
+// Loads the configuration and makes it current. Exits on errors.
+func (l *configLoader) mustLoad() *flag.FlagSet {
+	c, fs, err := l.load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	current.Store(&c)
+	return fs
+}
+
+func newSettings(c *config.Config) types.Settings {
+	settings := types.Settings{
+		Temperature: units.TempFromString(c.Temperature),
+		UdpAddress:  c.UdpAddress,
+		Race: strategy.Race{
+			Laps:     c.Race.Laps,
+			Duration: c.Race.Time.Duration,
+		},
+		TireWearLimit: c.Tires.WearLimit,
+		TireCompound:  c.Tires.Compound,
+		Widgets:       c.Widgets,
+	}
+	for _, w := range c.Tires.Windows {
+		if err := settings.TireWindows.Set(w, settings.Temperature); err != nil {
+			log.Error(err)
+		}
+	}
+	return settings
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+)
+
+func TestConfigLoaderFlagsWin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`temperature = "fahrenheit"
+[server]
+address = ":8000"
+`)
+
+	loader := newConfigLoader("test", []string{"--config", path, "--base-url", ":9000"}, func(fs *flag.FlagSet, c *config.Config) {
+		bindFlags(fs, c)
+	})
+	if loader.path != path {
+		t.Fatalf("config file %q, want %q", loader.path, path)
+	}
+	c, _, err := loader.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Temperature != "fahrenheit" || c.Server.Address != ":9000" {
+		t.Fatalf("got temperature %q and address %q", c.Temperature, c.Server.Address)
+	}
+
+	// A reload picks up the file again, and the command line still wins.
+	write(`temperature = "celsius"
+[server]
+address = ":8001"
+`)
+	c, _, err = loader.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Temperature != "celsius" || c.Server.Address != ":9000" {
+		t.Fatalf("after reload got temperature %q and address %q", c.Temperature, c.Server.Address)
+	}
+
+	write(`temperature = `)
+	if _, _, err := loader.load(); err == nil {
+		t.Fatal("invalid file loaded")
+	}
+}
+
+func TestRestartKeys(t *testing.T) {
+	old := config.Default()
+	c := config.Default()
+	c.Temperature = "fahrenheit"
+	c.Server.Ws = true
+	if keys := restartKeys(&old, &c); len(keys) != 0 {
+		t.Fatalf("got %v for options applied while running", keys)
+	}
+
+	c.UdpAddress = ":7000"
+	c.Forward.Targets = []string{"127.0.0.1:7778"}
+	want := []string{"udp_address", "forward.targets"}
+	if keys := restartKeys(&old, &c); !slices.Equal(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+const (
+	// Seconds at either end of the delta bar.
+	deltaRange = 2.0
+	// Width of each half of the delta bar.
+	deltaBarHalf = 20
+)
+
+// Renders the delta to the best lap as a bar growing left (gained, green) or right (lost, red).
+func DeltaWidget(d time.Duration, ok bool) string {
+	box := pterm.DefaultBox.WithTitle("Delta").WithBoxStyle(pterm.FgLightBlue.ToStyle())
+	if !ok {
+		return box.Sprint(pterm.FgDarkGray.Sprintf("%-*s", deltaBarHalf*2+1, "No reference lap"))
+	}
+
+	secs := d.Seconds()
+	n := int(math.Min(math.Abs(secs)/deltaRange, 1) * deltaBarHalf)
+	style := pterm.FgRed
+	if secs <= 0 {
+		style = pterm.FgGreen
+	}
+
+	left := strings.Repeat(" ", deltaBarHalf)
+	right := left
+	if secs < 0 {
+		left = strings.Repeat(" ", deltaBarHalf-n) + style.Sprint(strings.Repeat("█", n))
+	} else {
+		right = style.Sprint(strings.Repeat("█", n)) + strings.Repeat(" ", deltaBarHalf-n)
+	}
+
+	label := style.Sprintf("%+.3f", secs)
+	label = strings.Repeat(" ", deltaBarHalf-3) + label
+	return box.Sprint(label + "\n" + left + "│" + right)
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"github.com/guptarohit/asciigraph"
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/dyno"
+)
+
+// Horsepower per watt, as used by ForzaPacket.HorsePower.
+const hpPerWatt = 0.00134102
+
+// Returns the power (hp) and torque (Nm) of every bin from the lowest to the
+// highest, filling empty bins by interpolation.
+func dynoSeries(c *dyno.Curve) (power, torque []float64) {
+	first, last := c.Bins[0], c.Bins[len(c.Bins)-1]
+	n := int((last.Rpm-first.Rpm)/c.BinWidth) + 1
+	power = make([]float64, n)
+	torque = make([]float64, n)
+
+	for i := 0; i < len(c.Bins); i++ {
+		b := c.Bins[i]
+		at := int((b.Rpm - first.Rpm) / c.BinWidth)
+		power[at] = float64(b.Power) * hpPerWatt
+		torque[at] = float64(b.Torque)
+		if i == 0 {
+			continue
+		}
+		prev := c.Bins[i-1]
+		from := int((prev.Rpm - first.Rpm) / c.BinWidth)
+		for j := from + 1; j < at; j++ {
+			f := float64(j-from) / float64(at-from)
+			power[j] = power[from] + (power[at]-power[from])*f
+			torque[j] = torque[from] + (torque[at]-torque[from])*f
+		}
+	}
+	return power, torque
+}
+
+// Renders the power and torque curve of the current car and tune.
+func DynoWidget(c *dyno.Curve, ok bool) string {
+	box := pterm.DefaultBox.WithTitle("Dyno").WithBoxStyle(pterm.FgLightBlue.ToStyle())
+	if !ok || len(c.Bins) < 2 || !(c.BinWidth > 0) {
+		return box.Sprint(pterm.FgDarkGray.Sprint("No full-throttle pulls yet"))
+	}
+
+	power, torque := dynoSeries(c)
+	peakPower, peakTorque := c.Peaks()
+	first, last := c.Bins[0], c.Bins[len(c.Bins)-1]
+	chart := asciigraph.PlotMany([][]float64{power, torque},
+		asciigraph.SeriesColors(asciigraph.Red, asciigraph.Blue),
+		asciigraph.AxisColor(asciigraph.DimGray),
+		asciigraph.LabelColor(asciigraph.DarkGray),
+		asciigraph.LowerBound(0),
+		asciigraph.Height(8),
+		asciigraph.Width(50),
+		asciigraph.Precision(0),
+		asciigraph.Caption(pterm.Sprintf("%s %.0f hp @ %.0f | %s %.0f Nm @ %.0f | %.0f-%.0f rpm",
+			pterm.FgRed.Sprint("Power"), float64(peakPower.Power)*hpPerWatt, peakPower.Rpm,
+			pterm.FgBlue.Sprint("Torque"), peakTorque.Torque, peakTorque.Rpm,
+			first.Rpm, last.Rpm+c.BinWidth)))
+	return box.Sprint(chart)
+}
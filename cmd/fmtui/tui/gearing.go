@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/gearing"
+)
+
+// Renders the estimated ratio, spacing and top speed at the redline of every gear.
+func GearingWidget(r gearing.Report) string {
+	box := pterm.DefaultBox.WithTitle("Gearing").WithBoxStyle(pterm.FgLightBlue.ToStyle())
+	if len(r.Gears) == 0 {
+		return box.Sprint(pterm.FgDarkGray.Sprint("No steady driving yet"))
+	}
+
+	data := pterm.TableData{
+		{"Gear", "Ratio", "Step", "Top"},
+	}
+	for _, g := range r.Gears {
+		step := pterm.FgDarkGray.Sprint("   -")
+		if g.Spacing > 0 {
+			step = fmt.Sprintf("%4.2f", g.Spacing)
+		}
+		top := pterm.FgDarkGray.Sprint("   - km/h")
+		if g.TopSpeed > 0 {
+			top = fmt.Sprintf("%4.f km/h", g.TopSpeed*3.6)
+		}
+		data = append(data, []string{
+			fmt.Sprintf("%2d", g.Gear),
+			fmt.Sprintf("%6.2f", g.Ratio),
+			step,
+			top,
+		})
+	}
+
+	table, err := pterm.DefaultTable.WithHasHeader().WithLeftAlignment().WithData(data).Srender()
+	if err != nil {
+		log.Error(err)
+	}
+	radius := pterm.FgDarkGray.Sprint("Tire radius -")
+	if r.TireRadius > 0 {
+		radius = fmt.Sprintf("Tire radius %.3f m", r.TireRadius)
+	}
+	return box.Sprint(pterm.Sprintf("%s\n%s @ %.f rpm", table, radius, r.Redline))
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/gforce"
+)
+
+const (
+	// Size of the friction circle in terminal cells.
+	frictionWidth  = 20
+	frictionHeight = 10
+	// g at the edge of the friction circle.
+	frictionRange = 2.0
+)
+
+// Renders the friction circle: rings at 1g and 2g, the recent g-force trail
+// fading with age, the session peaks in each direction and the current g.
+func FrictionCircleWidget(current fmtel.GForce, trail []fmtel.GForce, peaks gforce.Peaks) string {
+	// Layers top to bottom: newest third of the trail, middle, oldest, rings.
+	canvas := newBrailleCanvas(frictionWidth, frictionHeight, 4)
+	cx := float64(frictionWidth*2-1) / 2
+	cy := float64(frictionHeight*4-1) / 2
+	radius := math.Min(cx, cy)
+	project := func(lateral, longitudinal float32) (int, int) {
+		x := cx + float64(lateral)/frictionRange*radius
+		// Braking pulls the dot down, towards the driver.
+		y := cy - float64(longitudinal)/frictionRange*radius
+		return int(math.Round(x)), int(math.Round(y))
+	}
+
+	for _, ring := range []float64{1, 2} {
+		r := ring / frictionRange * radius
+		for a := 0.0; a < 2*math.Pi; a += 0.05 {
+			canvas.set(3, int(math.Round(cx+r*math.Cos(a))), int(math.Round(cy+r*math.Sin(a))))
+		}
+	}
+	for x := 0; x < frictionWidth*2; x += 2 {
+		canvas.set(3, x, int(math.Round(cy)))
+	}
+	for y := 0; y < frictionHeight*4; y += 2 {
+		canvas.set(3, int(math.Round(cx)), y)
+	}
+
+	for i := 1; i < len(trail); i++ {
+		layer := 2 - i*3/len(trail)
+		x0, y0 := project(trail[i-1].Lateral, trail[i-1].Longitudinal)
+		x1, y1 := project(trail[i].Lateral, trail[i].Longitudinal)
+		canvas.line(layer, x0, y0, x1, y1)
+	}
+
+	marks := map[[2]int]string{}
+	mark := func(lateral, longitudinal float32, s string) {
+		x, y := project(lateral, longitudinal)
+		if x >= 0 && y >= 0 && x/2 < frictionWidth && y/4 < frictionHeight {
+			marks[[2]int{x / 2, y / 4}] = s
+		}
+	}
+	peak := pterm.FgYellow.Sprint("◆")
+	mark(-peaks.Left, 0, peak)
+	mark(peaks.Right, 0, peak)
+	mark(0, -peaks.Braking, peak)
+	mark(0, peaks.Acceleration, peak)
+	mark(current.Lateral, current.Longitudinal, pterm.FgRed.Sprint("●"))
+
+	circle := canvas.render([]pterm.Color{pterm.FgLightWhite, pterm.FgWhite, pterm.FgDarkGray, pterm.FgBlue}, marks)
+	label := fmt.Sprintf("\nLat %+4.2fg  Lon %+4.2fg\nPeak L %.2f R %.2f\n     B %.2f A %.2f",
+		current.Lateral, current.Longitudinal, peaks.Left, peaks.Right, peaks.Braking, peaks.Acceleration)
+	return pterm.DefaultBox.WithTitle("G-Force").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(circle + label)
+}
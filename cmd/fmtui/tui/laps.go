@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/types"
+	"github.com/stelmanjones/fmtel/laps"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+// Number of laps visible in the lap history at once.
+const LapHistoryRows = 6
+
+// Returns the scroll offset clamped to the available laps.
+func ClampLapScroll(scroll int, count int) int {
+	max := count - LapHistoryRows
+	if scroll > max {
+		scroll = max
+	}
+	if scroll < 0 {
+		scroll = 0
+	}
+	return scroll
+}
+
+func averageTemp(t fmtel.TireTemperatures, unit units.Temperature) float32 {
+	avg := (t.FrontLeft + t.FrontRight + t.RearLeft + t.RearRight) / 4
+	if unit == units.CELSIUS {
+		return (avg - 32) * 5 / 9
+	}
+	return avg
+}
+
+func averageWear(w fmtel.TireWear) float32 {
+	return (w.FrontLeft + w.FrontRight + w.RearLeft + w.RearRight) / 4
+}
+
+// Renders completed laps newest first, scrolled back by scroll laps.
+func LapHistoryWidget(history []laps.Lap, scroll int, settings *types.Settings) string {
+	if len(history) == 0 {
+		return pterm.DefaultBox.WithTitle("Laps").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint("No laps yet")
+	}
+
+	tempUnit := "°C"
+	if settings.Temperature == units.FAHRENHEIT {
+		tempUnit = "°F"
+	}
+
+	scroll = ClampLapScroll(scroll, len(history))
+	data := pterm.TableData{
+		{"Lap", "Time", "Top", "Min", "Fuel", "Wear", "Temp", "Lat g", "Brk g", "Avg g"},
+	}
+	for i := len(history) - 1 - scroll; i >= 0 && len(data) <= LapHistoryRows; i-- {
+		l := history[i]
+		lapTime := units.Timespan(l.Time).Format("04:05.000")
+		if !l.Valid {
+			lapTime = pterm.FgDarkGray.Sprint(lapTime)
+		}
+		data = append(data, []string{
+			fmt.Sprintf("%2d", l.Number),
+			lapTime,
+			fmt.Sprintf("%3.f km/h", l.TopSpeed*3.6),
+			fmt.Sprintf("%3.f km/h", l.MinCornerSpeed*3.6),
+			fmt.Sprintf("%4.1f%%", l.FuelUsed*100),
+			fmt.Sprintf("%4.1f%%", averageWear(l.TireWear)*100),
+			fmt.Sprintf("%3.f%s", averageTemp(l.AvgTireTemps, settings.Temperature), tempUnit),
+			fmt.Sprintf("%.2f", l.G.MaxLateral),
+			fmt.Sprintf("%.2f", l.G.MaxBraking),
+			fmt.Sprintf("%.2f", l.G.AvgCombined),
+		})
+	}
+
+	table, err := pterm.DefaultTable.WithHasHeader().WithLeftAlignment().WithData(data).Srender()
+	if err != nil {
+		log.Error(err)
+	}
+	title := "Laps"
+	if scroll > 0 {
+		title = fmt.Sprintf("Laps (-%d)", scroll)
+	}
+	return pterm.DefaultBox.WithTitle(title).WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(table)
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/shift"
+)
+
+// Width of the rpm bar in cells.
+const shiftBarWidth = 60
+
+// Fraction of the shift rpm at which the bar turns from green to yellow.
+const shiftWarning = 0.85
+
+// Fraction of max rpm used as the shift point of gears without one.
+const shiftFallback = 0.97
+
+// Renders an rpm bar that lights up towards the shift point of the current gear.
+func ShiftLightWidget(s shift.Status) string {
+	box := pterm.DefaultBox.WithTitle("Shift").WithBoxStyle(pterm.FgRed.ToStyle())
+	if s.MaxRpm <= 0 {
+		return box.Sprint(pterm.FgDarkGray.Sprint("No engine data yet"))
+	}
+
+	target := s.ShiftRpm
+	label := pterm.Sprintf("shift %5.f rpm", target)
+	if target <= 0 {
+		target = s.MaxRpm * shiftFallback
+		label = pterm.FgDarkGray.Sprintf("shift %5.f rpm", target)
+	}
+
+	cells := func(rpm float32) int {
+		return min(max(int(rpm/s.MaxRpm*shiftBarWidth), 0), shiftBarWidth)
+	}
+	lit := cells(s.Rpm)
+	mark := cells(target)
+
+	var bar strings.Builder
+	for i := 0; i < shiftBarWidth; i++ {
+		rpm := (float32(i) + 0.5) / shiftBarWidth * s.MaxRpm
+		style := pterm.FgGreen
+		switch {
+		case rpm >= target:
+			style = pterm.FgRed
+		case rpm >= target*shiftWarning:
+			style = pterm.FgYellow
+		}
+		switch {
+		case i == mark:
+			bar.WriteString(pterm.FgWhite.Sprint("|"))
+		case i < lit:
+			bar.WriteString(style.Sprint("█"))
+		default:
+			bar.WriteString(pterm.FgDarkGray.Sprint("░"))
+		}
+	}
+
+	light := pterm.FgDarkGray.Sprint("SHIFT")
+	if s.Rpm >= target {
+		light = pterm.BgRed.Sprint(pterm.FgWhite.Sprint("SHIFT"))
+	}
+	return box.Sprint(pterm.Sprintf("%s %s\nGear %2d  %5.f rpm  %s", bar.String(), light, s.Gear, s.Rpm, label))
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/strategy"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+// Renders the fuel burn and pit strategy for the rest of the race.
+func StrategyWidget(plan strategy.Plan, ok bool, race strategy.Race) string {
+	box := pterm.DefaultBox.WithTitle("Fuel").WithBoxStyle(pterm.FgLightBlue.ToStyle())
+	if !ok {
+		return box.Sprint(pterm.FgDarkGray.Sprintf("%-30s", "No fuel data yet"))
+	}
+
+	lines := fmt.Sprintf("Burn  %4.1f%%/lap  worst %4.1f%%\n", plan.Burn.Average*100, plan.Burn.Worst*100)
+	lines += fmt.Sprintf("Tank  %5.1f%%  %4.1f laps (%4.1f)\n", plan.Fuel*100, plan.FuelLaps, plan.FuelLapsWorst)
+
+	if !race.IsSet() {
+		lines += pterm.FgDarkGray.Sprint("Race  set --race-laps or --race-time\n")
+		lines += pterm.FgDarkGray.Sprint("Pit   -")
+		return box.Sprint(lines)
+	}
+
+	length := fmt.Sprintf("of %d", race.Laps)
+	if race.Laps == 0 {
+		length = "in " + units.Timespan(race.Duration).Format("15:04:05")
+	}
+	lines += fmt.Sprintf("Race  %4.1f laps left %s\n", plan.RaceLaps, length)
+
+	switch {
+	case plan.Stops == 0:
+		lines += pterm.FgGreen.Sprint("Pit   no stop needed")
+	case plan.Stops == 1:
+		lines += pterm.FgYellow.Sprintf("Pit   end of lap %d, add %4.1f%%", plan.PitLap, plan.FuelToAdd*100)
+	default:
+		lines += pterm.FgRed.Sprintf("Pit   end of lap %d, %d stops", plan.PitLap, plan.Stops)
+	}
+	return box.Sprint(lines)
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/types"
+	"github.com/stelmanjones/fmtel/tires"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+func tireCell(c tires.Corner, wear, temp, slip float32, unit string) string {
+	return fmt.Sprintf("%s %5.1f%% %3.f%s %4.2f", c, wear*100, temp, unit, slip)
+}
+
+// Renders wear, temperature and combined slip per corner, and the lap at which
+// the first tire is predicted to reach the wear limit.
+func TireWidget(packet *fmtel.ForzaPacket, tracker *tires.Tracker, settings *types.Settings) string {
+	wear := tires.Wear(packet)
+	temps := tires.Temps(packet)
+	slip := tires.Slip(packet)
+	unit := "°F"
+	if settings.Temperature == units.CELSIUS {
+		unit = "°C"
+		for i := range temps {
+			temps[i] = (temps[i] - 32) * 5 / 9
+		}
+	}
+
+	cells := [4]string{}
+	for _, c := range tires.Corners {
+		cells[c] = tireCell(c, wear[c], temps[c], slip[c], unit)
+	}
+	lines := cells[tires.FrontLeft] + "  " + cells[tires.FrontRight] + "\n" +
+		cells[tires.RearLeft] + "  " + cells[tires.RearRight] + "\n"
+
+	limit := settings.TireWearLimit
+	for _, c := range tires.Corners {
+		if wear[c] >= limit {
+			lines += pterm.FgRed.Sprintf("%s past %.0f%% wear", c, limit*100)
+			return pterm.DefaultBox.WithTitle("Tires").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(lines)
+		}
+	}
+
+	fit, ok := tracker.Fit()
+	prediction, predicted := fit.Predict(limit)
+	switch {
+	case !ok || !predicted:
+		lines += pterm.FgDarkGray.Sprintf("%.0f%% wear: no estimate yet", limit*100)
+	default:
+		left := prediction.Lap - float32(packet.LapNumber)
+		if left < 0 {
+			left = 0
+		}
+		style := pterm.FgGreen
+		if left < 3 {
+			style = pterm.FgYellow
+		}
+		lines += style.Sprintf("%s at %.0f%% wear in %.1f laps (lap %d)",
+			prediction.Corner, limit*100, left, int(prediction.Lap))
+	}
+
+	return pterm.DefaultBox.WithTitle("Tires").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(lines)
+}
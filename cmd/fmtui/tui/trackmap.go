@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/trackmap"
+)
+
+const (
+	// Size of the track map in terminal cells. Every cell holds 2x4 braille dots.
+	trackMapWidth  = 36
+	trackMapHeight = 12
+)
+
+// Bit of each dot in a braille cell, by row and column.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// brailleCanvas is a dot grid drawn with braille characters. Each layer is
+// coloured separately, with the first layer on top.
+type brailleCanvas struct {
+	width, height int
+	layers        [][][]rune
+}
+
+func newBrailleCanvas(width, height, layers int) *brailleCanvas {
+	c := &brailleCanvas{width: width, height: height}
+	for i := 0; i < layers; i++ {
+		cells := make([][]rune, height)
+		for y := range cells {
+			cells[y] = make([]rune, width)
+		}
+		c.layers = append(c.layers, cells)
+	}
+	return c
+}
+
+func (c *brailleCanvas) set(layer, x, y int) {
+	if x < 0 || y < 0 || x >= c.width*2 || y >= c.height*4 {
+		return
+	}
+	c.layers[layer][y/4][x/2] |= brailleDots[y%4][x%2]
+}
+
+func (c *brailleCanvas) line(layer, x0, y0, x1, y1 int) {
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(layer, x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Renders the canvas, colouring each cell with the style of its top layer.
+// marks replace whole cells and take precedence over every layer.
+func (c *brailleCanvas) render(styles []pterm.Color, marks map[[2]int]string) string {
+	var b strings.Builder
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			if mark, ok := marks[[2]int{x, y}]; ok {
+				b.WriteString(mark)
+				continue
+			}
+			var dots rune
+			style := -1
+			for i := len(c.layers) - 1; i >= 0; i-- {
+				if d := c.layers[i][y][x]; d != 0 {
+					dots |= d
+					style = i
+				}
+			}
+			if style < 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteString(styles[style].Sprint(string(0x2800 + dots)))
+		}
+		if y < c.height-1 {
+			b.WriteRune('\n')
+		}
+	}
+	return b.String()
+}
+
+// Renders the learned track outline with the start/finish line, the car and,
+// if showBest is set, the trace of the best lap.
+func TrackMapWidget(m trackmap.Map, ok bool, best []trackmap.Point, showBest bool, car trackmap.Point) string {
+	box := pterm.DefaultBox.WithTitle("Track Map").WithBoxStyle(pterm.FgLightBlue.ToStyle())
+	if !ok {
+		empty := pterm.FgDarkGray.Sprintf("%-*s", trackMapWidth, "Learning track, drive a clean lap")
+		return box.Sprint(empty + strings.Repeat("\n", trackMapHeight-1))
+	}
+
+	min, max := m.Bounds()
+	spanX := float64(max.X - min.X)
+	spanZ := float64(max.Z - min.Z)
+	dotsX := float64(trackMapWidth*2 - 1)
+	dotsY := float64(trackMapHeight*4 - 1)
+	scale := math.Min(dotsX/math.Max(spanX, 1), dotsY/math.Max(spanZ, 1))
+	// Centre the track in the spare room.
+	offX := (dotsX - spanX*scale) / 2
+	offY := (dotsY - spanZ*scale) / 2
+	project := func(p trackmap.Point) (int, int) {
+		x := offX + float64(p.X-min.X)*scale
+		// World Z grows away from the viewer, screen rows grow downwards.
+		y := dotsY - offY - float64(p.Z-min.Z)*scale
+		return int(math.Round(x)), int(math.Round(y))
+	}
+
+	canvas := newBrailleCanvas(trackMapWidth, trackMapHeight, 2)
+	draw := func(layer int, points []trackmap.Point) {
+		for i := 1; i < len(points); i++ {
+			x0, y0 := project(points[i-1])
+			x1, y1 := project(points[i])
+			canvas.line(layer, x0, y0, x1, y1)
+		}
+	}
+	// The best lap goes on top so it shows where it runs along the outline.
+	if showBest {
+		draw(0, best)
+	}
+	draw(1, m.Outline)
+
+	marks := map[[2]int]string{}
+	if start, ok := m.Start(); ok {
+		x, y := project(start)
+		marks[[2]int{x / 2, y / 4}] = pterm.FgGreen.Sprint("▮")
+	}
+	x, y := project(car)
+	if x >= 0 && y >= 0 && x/2 < trackMapWidth && y/4 < trackMapHeight {
+		marks[[2]int{x / 2, y / 4}] = pterm.FgRed.Sprint("●")
+	}
+
+	return box.Sprint(canvas.render([]pterm.Color{pterm.FgCyan, pterm.FgWhite}, marks))
+}
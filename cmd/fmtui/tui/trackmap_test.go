@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pterm/pterm"
+	"github.com/stelmanjones/fmtel/trackmap"
+)
+
+var square = []trackmap.Point{{X: 0, Z: 0}, {X: 100, Z: 0}, {X: 100, Z: 100}, {X: 0, Z: 100}, {X: 0, Z: 0}}
+
+func TestBrailleCanvasLine(t *testing.T) {
+	c := newBrailleCanvas(2, 1, 1)
+	c.line(0, 0, 0, 3, 0)
+	if got := pterm.RemoveColorFromString(c.render([]pterm.Color{pterm.FgDefault}, nil)); got != "⠉⠉" {
+		t.Fatalf("got %q, want the top row of dots in both cells", got)
+	}
+}
+
+func TestTrackMapWidgetBestOnTop(t *testing.T) {
+	pterm.EnableColor()
+	m := trackmap.Map{TrackOrdinal: 1, Outline: square}
+	car := trackmap.Point{X: 50, Z: 50}
+	// The colour codes the canvas starts cells with.
+	cyan, _, _ := strings.Cut(pterm.FgCyan.Sprint("x"), "x")
+	white, _, _ := strings.Cut(pterm.FgWhite.Sprint("x"), "x")
+
+	// The best lap follows the outline exactly, so it has to cover all of it.
+	out := TrackMapWidget(m, true, square, true, car)
+	if strings.Contains(out, white) || !strings.Contains(out, cyan) {
+		t.Fatalf("best lap hidden under the outline:\n%s", out)
+	}
+
+	out = TrackMapWidget(m, true, square, false, car)
+	if strings.Contains(out, cyan) || !strings.Contains(out, white) {
+		t.Fatalf("outline not drawn on its own:\n%s", out)
+	}
+}
+
+func TestTrackMapWidgetLearning(t *testing.T) {
+	out := TrackMapWidget(trackmap.Map{}, false, nil, true, trackmap.Point{})
+	if !strings.Contains(out, "Learning track") {
+		t.Fatalf("got %q", out)
+	}
+}
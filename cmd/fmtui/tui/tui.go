@@ -7,8 +7,14 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/pterm/pterm"
 	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/cars"
 	"github.com/stelmanjones/fmtel/cmd/fmtui/pedals"
 	"github.com/stelmanjones/fmtel/cmd/fmtui/types"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/strategy"
+	"github.com/stelmanjones/fmtel/tires"
+	"github.com/stelmanjones/fmtel/trackmap"
+	"github.com/stelmanjones/fmtel/tracks"
 	"github.com/stelmanjones/fmtel/units"
 )
 
@@ -38,39 +44,128 @@ func PedalWidget(packet *fmtel.ForzaPacket) string {
 	return final
 }
 
-func WheelTempWidget(packet *fmtel.ForzaPacket, settings *types.Settings) string {
-	var temps fmtel.TireTemperatures
+// Temperatures further than this outside the window in fahrenheit get the full cold or hot colour.
+const heatSpread = 30
 
-	switch settings.Temperature {
-	case "fahrenheit":
-		temps = fmtel.TireTemperatures{
-			FrontLeft:  packet.TireTempFrontLeft,
-			FrontRight: packet.TireTempFrontRight,
-			RearLeft:   packet.TireTempRearLeft,
-			RearRight:  packet.TireTempRearRight,
-		}
+// Change per second in fahrenheit below which the trend is shown as steady.
+const trendSteady = 0.5
+
+var (
+	heatCold    = pterm.NewRGB(0, 120, 255)
+	heatOptimal = pterm.NewRGB(0, 200, 0)
+	heatHot     = pterm.NewRGB(255, 0, 0)
+)
+
+func lerpRGB(a, b pterm.RGB, f float32) pterm.RGB {
+	if f < 0 {
+		f = 0
+	}
+	if f > 1 {
+		f = 1
+	}
+	mix := func(x, y uint8) uint8 {
+		return uint8(float32(x) + (float32(y)-float32(x))*f)
+	}
+	return pterm.NewRGB(mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B))
+}
+
+// Returns the heat map colour of a temperature in fahrenheit.
+func heatColor(w tires.Window, temp float32) pterm.RGB {
+	switch w.Status(temp) {
+	case tires.Cold:
+		return lerpRGB(heatCold, heatOptimal, 1-(w.Min-temp)/heatSpread)
+	case tires.Hot:
+		return lerpRGB(heatOptimal, heatHot, (temp-w.Max)/heatSpread)
 	default:
-		{
-			temps = *packet.TireTempsInCelsius()
-		}
+		return heatOptimal
+	}
+}
+
+func trendArrow(rate float32) string {
+	switch {
+	case rate > trendSteady:
+		return "↑"
+	case rate < -trendSteady:
+		return "↓"
+	default:
+		return "→"
 	}
+}
 
-	var template string
-	switch settings.Temperature {
+// Renders tire temperatures coloured against the optimal window, with a trend
+// arrow per tire and the front-rear and left-right balance.
+func WheelTempWidget(packet *fmtel.ForzaPacket, trend *tires.TempTrend, settings *types.Settings) string {
+	window := settings.TireWindows.For(settings.TireCompound)
+	temps := tires.Temps(packet)
+	rates, _ := trend.Rate()
 
-	case units.CELSIUS:
-		template = pterm.Sprintf("\n        F  \n%3.f°C █   █ %3.f°C \n\n%3.f°C █   █ %3.f°C \n        R\n", temps.FrontLeft, temps.FrontRight, temps.RearLeft, temps.RearRight)
-	case units.FAHRENHEIT:
-		template = pterm.Sprintf("\n        F  \n%3.f°F █   █ %3.f°F \n\n%3.f°F █   █ %3.f°F \n        R\n", temps.FrontLeft, temps.FrontRight, temps.RearLeft, temps.RearRight)
+	unit := "°F"
+	convert := func(t float32) float32 { return t }
+	delta := convert
+	if settings.Temperature == units.CELSIUS {
+		unit = "°C"
+		convert = func(t float32) float32 { return (t - 32) * 5 / 9 }
+		delta = func(t float32) float32 { return t * 5 / 9 }
 	}
 
+	var cells [4]string
+	var blocks [4]string
+	for _, c := range tires.Corners {
+		color := heatColor(window, temps[c])
+		cells[c] = color.Sprintf("%3.f%s", convert(temps[c]), unit) + trendArrow(rates[c])
+		blocks[c] = color.Sprint("█")
+	}
+	frontRear, leftRight := tires.Balance(temps)
+
+	template := pterm.Sprintf("\n         F  \n%s %s   %s %s \n\n%s %s   %s %s \n         R\n\nF-R %+3.f%s  L-R %+3.f%s",
+		cells[tires.FrontLeft], blocks[tires.FrontLeft], blocks[tires.FrontRight], cells[tires.FrontRight],
+		cells[tires.RearLeft], blocks[tires.RearLeft], blocks[tires.RearRight], cells[tires.RearRight],
+		delta(frontRear), unit, delta(leftRight), unit)
+
 	final := pterm.DefaultBox.WithBoxStyle(pterm.FgWhite.ToStyle()).WithTitle("Tire Temps").Sprint(template)
 	return final
 }
 
-func Render(packet *fmtel.ForzaPacket, app *types.App) string {
+func carLabel(c *cars.Car) string {
+	unnamed := func(s, def string) bool { return s == "" || s == def }
+	if unnamed(c.Maker, cars.DefaultCar.Maker) && unnamed(c.Model, cars.DefaultCar.Model) {
+		return pterm.FgDarkGray.Sprintf("Unknown Car (%d)", c.CarOrdinal)
+	}
+	label := pterm.FgWhite.Sprint(c.Maker) + " " + c.Model
+	if c.Year > 0 {
+		label += " " + pterm.FgDarkGray.Sprintf("(%d)", c.Year)
+	}
+	return label
+}
+
+func trackLabel(t *tracks.Track, received *decoder.Packet) string {
+	if !received.HasField("TrackOrdinal") {
+		return pterm.FgDarkGray.Sprintf("Track not sent in the %s format", received.Format)
+	}
+	if t.Circuit == tracks.DefaultTrack.Circuit {
+		return pterm.FgDarkGray.Sprintf("Unknown Track (%d)", t.TrackOrdinal)
+	}
+	return pterm.FgWhite.Sprint(t.Name())
+}
+
+// Returns a placeholder for a panel whose values the packet format does not carry.
+func notSent(title string, received *decoder.Packet) string {
+	return pterm.DefaultBox.WithTitle(title).WithBoxStyle(pterm.FgDarkGray.ToStyle()).
+		Sprint(pterm.FgDarkGray.Sprintf("Not sent in the %s format", received.Format))
+}
+
+func Render(received *decoder.Packet, app *types.App) string {
+	packet := &received.ForzaPacket
 	currentCar := app.CurrentCar
 
+	// Renders a panel, or a placeholder if the source does not send the fields it needs.
+	panel := func(needs decoder.Fields, title string, render func() string) pterm.Panel {
+		if !received.Has(needs) {
+			return pterm.Panel{Data: notSent(title, received)}
+		}
+		return pterm.Panel{Data: render()}
+	}
+
 	boost := func() float32 {
 		if packet.Boost <= 0 {
 			return 0.0
@@ -141,14 +236,93 @@ func Render(packet *fmtel.ForzaPacket, app *types.App) string {
 				Add(*pterm.
 					Bold.
 					ToStyle()).
-				Sprintf("\n\nFMTEL | Version: 0.1.1 \n\n%s %s %s\n\n", pterm.FgWhite.Sprint(currentCar.Maker), currentCar.Model, pterm.FgDarkGray.ToStyle().Sprintf("(%d)", currentCar.Year)))
-	tires := WheelTempWidget(packet, &app.Settings)
-	layout, err := pterm.DefaultPanel.WithPadding(4).WithPanels(pterm.Panels{
-		{{Data: title}},
-		{{Data: pterm.DefaultBox.WithTitle("Race Info").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(lapStats)}, {Data: tires}},
-		{{Data: pterm.Sprintf("%s", stats)}},
-		{{Data: pedals}},
-	}).Srender()
+				Sprintf("\n\nFMTEL | Version: 0.1.1 \n\n%s | %s\n\n", carLabel(&currentCar), trackLabel(&app.CurrentTrack, received)))
+	widgets := app.Settings.Widgets
+	var info, race []pterm.Panel
+	if widgets.RaceInfo {
+		info = append(info, panel(decoder.DashFields, "Race Info", func() string {
+			return pterm.DefaultBox.WithTitle("Race Info").WithBoxStyle(pterm.FgLightBlue.ToStyle()).Sprint(lapStats)
+		}))
+	}
+	if widgets.Laps {
+		info = append(info, panel(decoder.DashFields, "Laps", func() string {
+			return LapHistoryWidget(app.Laps.Laps(), app.LapScroll, &app.Settings)
+		}))
+	}
+	if widgets.TireTemps {
+		info = append(info, panel(decoder.DashFields, "Tire Temps", func() string {
+			return WheelTempWidget(packet, app.TempTrend, &app.Settings)
+		}))
+	}
+	if widgets.Delta {
+		race = append(race, panel(decoder.DashFields, "Delta", func() string {
+			return DeltaWidget(app.Delta.Delta())
+		}))
+	}
+	if widgets.Fuel {
+		race = append(race, panel(decoder.DashFields, "Fuel", func() string {
+			plan, ok := app.Settings.Race.Plan(packet, app.Laps.Laps(), strategy.DefaultWindow)
+			return StrategyWidget(plan, ok, app.Settings.Race)
+		}))
+	}
+	if widgets.Tires {
+		race = append(race, panel(decoder.ExtendedFields, "Tires", func() string {
+			return TireWidget(packet, app.Tires, &app.Settings)
+		}))
+	}
+
+	panels := pterm.Panels{{{Data: title}}}
+	if widgets.ShiftLight {
+		panels = append(panels, []pterm.Panel{panel(decoder.DashFields, "Shift", func() string {
+			return ShiftLightWidget(app.Shift.Status())
+		})})
+	}
+	for _, row := range [][]pterm.Panel{info, race} {
+		if len(row) > 0 {
+			panels = append(panels, row)
+		}
+	}
+	var extra []pterm.Panel
+	if widgets.Stats {
+		extra = append(extra, pterm.Panel{Data: pterm.Sprintf("%s", stats)})
+	}
+	if widgets.TrackMap {
+		extra = append(extra, panel(decoder.DashFields, "Track Map", func() string {
+			m, ok := app.TrackMap.Map()
+			return TrackMapWidget(m, ok, app.TrackMap.Best(), widgets.TrackMapBest, trackmap.Position(packet))
+		}))
+	}
+	if len(extra) > 0 {
+		panels = append(panels, extra)
+	}
+	var tuning []pterm.Panel
+	if widgets.Dyno {
+		tuning = append(tuning, panel(decoder.DashFields, "Dyno", func() string {
+			curve, ok := app.Dyno.Current()
+			return DynoWidget(curve, ok)
+		}))
+	}
+	if widgets.Gearing {
+		tuning = append(tuning, panel(decoder.DashFields, "Gearing", func() string {
+			return GearingWidget(app.Gearing.Report())
+		}))
+	}
+	if len(tuning) > 0 {
+		panels = append(panels, tuning)
+	}
+	var inputs []pterm.Panel
+	if widgets.Pedals {
+		inputs = append(inputs, panel(decoder.DashFields, "Pedals", func() string {
+			return pedals
+		}))
+	}
+	if widgets.GForce {
+		inputs = append(inputs, pterm.Panel{Data: FrictionCircleWidget(*packet.GForce(), app.GTrail.Points(), app.GTrail.Peaks())})
+	}
+	if len(inputs) > 0 {
+		panels = append(panels, inputs)
+	}
+	layout, err := pterm.DefaultPanel.WithPadding(4).WithPanels(panels).Srender()
 	if err != nil {
 		log.Error(err)
 	}
@@ -2,19 +2,49 @@ package types
 
 import (
 	"github.com/stelmanjones/fmtel/cars"
+	"github.com/stelmanjones/fmtel/cmd/fmtui/config"
+	"github.com/stelmanjones/fmtel/delta"
+	"github.com/stelmanjones/fmtel/dyno"
+	"github.com/stelmanjones/fmtel/gearing"
+	"github.com/stelmanjones/fmtel/gforce"
+	"github.com/stelmanjones/fmtel/laps"
+	"github.com/stelmanjones/fmtel/shift"
+	"github.com/stelmanjones/fmtel/strategy"
+	"github.com/stelmanjones/fmtel/tires"
+	"github.com/stelmanjones/fmtel/trackmap"
+	"github.com/stelmanjones/fmtel/tracks"
 	"github.com/stelmanjones/fmtel/units"
 )
 
 type App struct {
 	Settings        Settings
-	CarList         []cars.Car
+	Cars            *cars.Database
 	GraphData       [][]float64
 	CurrentCar      cars.Car
+	Tracks          *tracks.Database
+	CurrentTrack    tracks.Track
 	GraphDataPoints int
+	Laps            *laps.Tracker
+	Delta           *delta.Tracker
+	Tires           *tires.Tracker
+	TempTrend       *tires.TempTrend
+	TrackMap        *trackmap.Tracker
+	GTrail          *gforce.Trail
+	Dyno            *dyno.Dyno
+	Gearing         *gearing.Analyzer
+	Shift           *shift.Advisor
+	// Number of laps the lap history is scrolled back by.
+	LapScroll int
 }
 
 type Settings struct {
 	Temperature units.Temperature
 	UdpAddress  string
+	Race        strategy.Race
+	// Tire wear (0 to 1) the stint prediction counts down to.
+	TireWearLimit float32
+	TireWindows   tires.Windows
+	// Compound whose window in TireWindows applies.
+	TireCompound string
+	Widgets      config.Widgets
 }
-
@@ -0,0 +1,276 @@
+package decoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Packet layout sent by the game.
+type Format uint8
+
+const (
+	// Detect the layout from the datagram length.
+	Auto Format = iota
+	// Forza Motorsport 7 "Sled" layout.
+	Sled
+	// Forza Motorsport 7 "Dash" layout.
+	Dash
+	// Forza Horizon 4/5 layout.
+	Horizon
+	// Forza Motorsport (2023) layout.
+	Motorsport
+)
+
+// Datagram sizes in bytes for each layout.
+const (
+	SledSize       = 232
+	DashSize       = 311
+	HorizonSize    = 324
+	MotorsportSize = 331
+)
+
+// Horizon inserts 12 undocumented bytes between the sled and dash sections.
+const (
+	horizonGapStart = SledSize
+	horizonGapEnd   = SledSize + 12
+)
+
+// Groups of ForzaPacket fields carried by a layout.
+type Fields uint8
+
+const (
+	// Fields up to and including NumCylinders.
+	SledFields Fields = 1 << iota
+	// PositionX through NormalizedAIBrakeDifference.
+	DashFields
+	// Tire wear and TrackOrdinal.
+	ExtendedFields
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown packet format")
+	ErrSizeMismatch  = errors.New("datagram size does not match packet format")
+)
+
+// Packet is a decoded datagram together with the layout it came from.
+type Packet struct {
+	fmtel.ForzaPacket
+	Format Format
+	Fields Fields
+}
+
+// Returns true if the source carried every field in f.
+func (p *Packet) Has(f Fields) bool {
+	return p.Fields&f == f
+}
+
+// Returns true if the source carried the ForzaPacket field with the given name.
+func (p *Packet) HasField(name string) bool {
+	f, ok := FieldGroup(name)
+	if !ok {
+		return false
+	}
+	return p.Has(f)
+}
+
+// Returns the group the ForzaPacket field with the given name belongs to.
+func FieldGroup(name string) (Fields, bool) {
+	f, ok := fieldGroups[name]
+	return f, ok
+}
+
+// Encodes the fields the source carried as a JSON object, in ForzaPacket order.
+// Fields the layout does not carry are left out rather than sent as zero.
+func (p *Packet) ToJson() ([]byte, error) {
+	all := SledFields | DashFields | ExtendedFields
+	if p.Has(all) {
+		return p.ForzaPacket.ToJson()
+	}
+
+	v := reflect.ValueOf(&p.ForzaPacket).Elem()
+	t := v.Type()
+	b := []byte{'{'}
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if !p.HasField(name) {
+			continue
+		}
+		value, err := json.Marshal(v.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, name)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+	return append(b, '}'), nil
+}
+
+// Maps every ForzaPacket field name to the group it belongs to.
+var fieldGroups = func() map[string]Fields {
+	groups := make(map[string]Fields)
+	t := reflect.TypeOf(fmtel.ForzaPacket{})
+	offset := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case offset < SledSize:
+			groups[field.Name] = SledFields
+		case offset < DashSize:
+			groups[field.Name] = DashFields
+		default:
+			groups[field.Name] = ExtendedFields
+		}
+		offset += int(field.Type.Size())
+	}
+	return groups
+}()
+
+func FormatFromString(s string) Format {
+	switch s {
+	case "sled":
+		return Sled
+	case "dash":
+		return Dash
+	case "horizon", "fh4", "fh5":
+		return Horizon
+	case "motorsport", "fm", "fm2023":
+		return Motorsport
+	default:
+		return Auto
+	}
+}
+
+func (f Format) String() string {
+	switch f {
+	case Sled:
+		return "sled"
+	case Dash:
+		return "dash"
+	case Horizon:
+		return "horizon"
+	case Motorsport:
+		return "motorsport"
+	default:
+		return "auto"
+	}
+}
+
+// Returns the datagram size of the layout, or 0 for Auto.
+func (f Format) Size() int {
+	switch f {
+	case Sled:
+		return SledSize
+	case Dash:
+		return DashSize
+	case Horizon:
+		return HorizonSize
+	case Motorsport:
+		return MotorsportSize
+	default:
+		return 0
+	}
+}
+
+// Returns the field groups carried by the layout.
+func (f Format) Fields() Fields {
+	switch f {
+	case Sled:
+		return SledFields
+	case Dash, Horizon:
+		return SledFields | DashFields
+	case Motorsport:
+		return SledFields | DashFields | ExtendedFields
+	default:
+		return 0
+	}
+}
+
+// Returns the layout matching a datagram of n bytes.
+func Detect(n int) (Format, error) {
+	switch n {
+	case SledSize:
+		return Sled, nil
+	case DashSize:
+		return Dash, nil
+	case HorizonSize:
+		return Horizon, nil
+	case MotorsportSize:
+		return Motorsport, nil
+	default:
+		return Auto, fmt.Errorf("%w: %d bytes", ErrUnknownFormat, n)
+	}
+}
+
+// Decodes a datagram in the given layout. Auto detects the layout from the datagram length.
+func Decode(b []byte, format Format) (Packet, error) {
+	var packet Packet
+	if format == Auto {
+		f, err := Detect(len(b))
+		if err != nil {
+			return packet, err
+		}
+		format = f
+	}
+	if len(b) < format.Size() {
+		return packet, fmt.Errorf("%w: got %d bytes, %s needs %d", ErrSizeMismatch, len(b), format, format.Size())
+	}
+
+	var err error
+	switch format {
+	case Motorsport:
+		err = packet.UnmarshalBinary(b)
+	case Horizon:
+		var buf [MotorsportSize]byte
+		copy(buf[:], b[:horizonGapStart])
+		copy(buf[horizonGapStart:DashSize], b[horizonGapEnd:])
+		err = packet.UnmarshalBinary(buf[:])
+	default:
+		var buf [MotorsportSize]byte
+		copy(buf[:], b[:format.Size()])
+		err = packet.UnmarshalBinary(buf[:])
+	}
+	if err != nil {
+		return packet, err
+	}
+	packet.Format = format
+	packet.Fields = format.Fields()
+	return packet, nil
+}
+
+var ErrConversion = errors.New("unsupported format conversion")
+
+// Appends datagram b, sent in layout from, to dst in layout to.
+// Only conversions that drop fields are supported, such as Dash to Sled.
+func AppendConverted(dst []byte, b []byte, from Format, to Format) ([]byte, error) {
+	if from == Auto {
+		f, err := Detect(len(b))
+		if err != nil {
+			return dst, err
+		}
+		from = f
+	}
+	if len(b) < from.Size() {
+		return dst, fmt.Errorf("%w: got %d bytes, %s needs %d", ErrSizeMismatch, len(b), from, from.Size())
+	}
+	if to == Auto || to == from {
+		return append(dst, b[:from.Size()]...), nil
+	}
+	if to == Horizon || from.Fields()&to.Fields() != to.Fields() {
+		return dst, fmt.Errorf("%w: %s to %s", ErrConversion, from, to)
+	}
+
+	if from == Horizon {
+		dst = append(dst, b[:horizonGapStart]...)
+		return append(dst, b[horizonGapEnd:horizonGapEnd+to.Size()-SledSize]...), nil
+	}
+	return append(dst, b[:to.Size()]...), nil
+}
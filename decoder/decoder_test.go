@@ -0,0 +1,44 @@
+package decoder
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToJsonLeavesOutMissingFields(t *testing.T) {
+	for _, c := range []struct {
+		format  Format
+		present []string
+		missing []string
+	}{
+		{Sled, []string{"IsRaceOn", "NumCylinders"}, []string{"Speed", "TireWearFrontLeft", "TrackOrdinal"}},
+		{Dash, []string{"Speed", "NormalizedAIBrakeDifference"}, []string{"TireWearFrontLeft", "TrackOrdinal"}},
+		{Horizon, []string{"Speed"}, []string{"TrackOrdinal"}},
+		{Motorsport, []string{"Speed", "TireWearRearRight", "TrackOrdinal"}, nil},
+	} {
+		t.Run(c.format.String(), func(t *testing.T) {
+			p, err := Decode(make([]byte, c.format.Size()), c.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := p.ToJson()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatalf("%s: %v", b, err)
+			}
+			for _, name := range c.present {
+				if _, ok := m[name]; !ok {
+					t.Errorf("%s missing", name)
+				}
+			}
+			for _, name := range c.missing {
+				if _, ok := m[name]; ok {
+					t.Errorf("%s sent although %s does not carry it", name, c.format)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package delta
+
+import (
+	"sort"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// A lap that starts with more than this on the lap clock was joined in progress.
+const lapStartTolerance = 1.0
+
+type sample struct {
+	// Distance from the start of the lap in meters.
+	Distance float32
+	// Lap time at Distance in seconds.
+	Time float32
+}
+
+// Tracker compares the lap in progress against the best lap of the session,
+// lining both up by distance travelled since the start of the lap.
+type Tracker struct {
+	best     []sample
+	bestTime float32
+
+	current  []sample
+	complete bool
+	lapStart float32
+	last     fmtel.ForzaPacket
+	hasLast  bool
+
+	delta    float32
+	hasDelta bool
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+func (t *Tracker) startLap(p *fmtel.ForzaPacket) {
+	t.current = t.current[:0]
+	t.complete = p.CurrentLap <= lapStartTolerance
+	t.lapStart = p.DistanceTraveled
+}
+
+// Feeds a packet to the tracker. Only packets sent while a race is on should be passed in.
+func (t *Tracker) Update(p *fmtel.ForzaPacket) {
+	if !t.hasLast {
+		t.startLap(p)
+	} else {
+		prev := &t.last
+		switch {
+		case p.LapNumber > prev.LapNumber:
+			lapTime := p.LastLap
+			if lapTime <= 0 && len(t.current) > 0 {
+				lapTime = t.current[len(t.current)-1].Time
+			}
+			if t.complete && len(t.current) > 1 && (t.best == nil || lapTime < t.bestTime) {
+				t.best = append(t.best[:0], t.current...)
+				t.bestTime = lapTime
+			}
+			t.current = make([]sample, 0, len(t.best))
+			t.startLap(p)
+		case p.LapNumber < prev.LapNumber:
+			t.startLap(p)
+		case p.CurrentLap < prev.CurrentLap:
+			// Rewinds move the car back along the lap, so drop everything past it.
+			d := p.DistanceTraveled - t.lapStart
+			n := sort.Search(len(t.current), func(i int) bool {
+				return t.current[i].Distance > d
+			})
+			t.current = t.current[:n]
+		}
+	}
+	t.last = *p
+	t.hasLast = true
+
+	d := p.DistanceTraveled - t.lapStart
+	if d < 0 {
+		t.hasDelta = false
+		return
+	}
+	if len(t.current) == 0 || d > t.current[len(t.current)-1].Distance {
+		t.current = append(t.current, sample{Distance: d, Time: p.CurrentLap})
+	}
+
+	ref, ok := t.timeAt(d)
+	t.hasDelta = ok && t.complete
+	if t.hasDelta {
+		t.delta = p.CurrentLap - ref
+	}
+}
+
+// Returns the best lap's time at distance d by linear interpolation.
+func (t *Tracker) timeAt(d float32) (float32, bool) {
+	if len(t.best) < 2 || d > t.best[len(t.best)-1].Distance {
+		return 0, false
+	}
+	i := sort.Search(len(t.best), func(i int) bool {
+		return t.best[i].Distance >= d
+	})
+	if i == 0 {
+		return t.best[0].Time, true
+	}
+	a, b := t.best[i-1], t.best[i]
+	f := (d - a.Distance) / (b.Distance - a.Distance)
+	return a.Time + f*(b.Time-a.Time), true
+}
+
+// Returns the time gained (negative) or lost (positive) against the best lap at the
+// current point on track. Returns false until a complete best lap has been driven.
+func (t *Tracker) Delta() (time.Duration, bool) {
+	return time.Duration(float64(t.delta) * float64(time.Second)), t.hasDelta
+}
+
+// Returns the reference lap time, or 0 if there is none yet.
+func (t *Tracker) Best() time.Duration {
+	return time.Duration(float64(t.bestTime) * float64(time.Second))
+}
+
+// Forgets the reference lap and the lap in progress.
+func (t *Tracker) Reset() {
+	*t = Tracker{}
+}
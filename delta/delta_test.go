@@ -0,0 +1,111 @@
+package delta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Length of the test track in meters.
+const lapLength = 1000
+
+// Drives lap number lap at a constant speed (m/s) from the distance start,
+// up to distance into the lap. Returns the last packet fed.
+func drive(tr *Tracker, lap uint16, start, speed, distance float32) fmtel.ForzaPacket {
+	var p fmtel.ForzaPacket
+	for d := float32(0); d <= distance; d += 25 {
+		p = fmtel.ForzaPacket{
+			LapNumber:        lap,
+			CurrentLap:       d / speed,
+			DistanceTraveled: start + d,
+		}
+		tr.Update(&p)
+	}
+	return p
+}
+
+// Drives a full lap and crosses the line onto the next one.
+func fullLap(tr *Tracker, lap uint16, speed float32) {
+	start := float32(lap) * lapLength
+	drive(tr, lap, start, speed, lapLength-25)
+	tr.Update(&fmtel.ForzaPacket{
+		LapNumber:        lap + 1,
+		LastLap:          lapLength / speed,
+		DistanceTraveled: start + lapLength,
+	})
+}
+
+func TestNoReferenceYet(t *testing.T) {
+	tr := NewTracker()
+	drive(tr, 0, 0, 50, 500)
+	if _, ok := tr.Delta(); ok {
+		t.Fatal("delta without a reference lap")
+	}
+	if tr.Best() != 0 {
+		t.Fatalf("best %v without a reference lap", tr.Best())
+	}
+}
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float32
+		want  time.Duration
+	}{
+		// The reference lap is at 50 m/s, so it is at 500m after 10s.
+		{"slower", 40, 2500 * time.Millisecond},
+		{"faster", 62.5, -2 * time.Second},
+		{"same pace", 50, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracker()
+			fullLap(tr, 0, 50)
+			drive(tr, 1, lapLength, tt.speed, 500)
+			got, ok := tr.Delta()
+			if !ok {
+				t.Fatal("no delta")
+			}
+			if diff := got - tt.want; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Fatalf("delta %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferenceReplacedByFasterLap(t *testing.T) {
+	tr := NewTracker()
+	fullLap(tr, 0, 50)
+	if tr.Best() != 20*time.Second {
+		t.Fatalf("best %v, want 20s", tr.Best())
+	}
+
+	// A slower lap keeps the reference.
+	fullLap(tr, 1, 40)
+	if tr.Best() != 20*time.Second {
+		t.Fatalf("best %v after a slower lap, want 20s", tr.Best())
+	}
+
+	// A faster lap replaces it, and the next lap is compared against it.
+	fullLap(tr, 2, 62.5)
+	if tr.Best() != 16*time.Second {
+		t.Fatalf("best %v after a faster lap, want 16s", tr.Best())
+	}
+	drive(tr, 3, 3*lapLength, 50, 500)
+	got, ok := tr.Delta()
+	if !ok || got < 1999*time.Millisecond || got > 2001*time.Millisecond {
+		t.Fatalf("delta %v against the new reference, want 2s", got)
+	}
+}
+
+func TestLapJoinedInProgressIsNotAReference(t *testing.T) {
+	tr := NewTracker()
+	// Joined 5s into the lap.
+	tr.Update(&fmtel.ForzaPacket{CurrentLap: 5, DistanceTraveled: 250})
+	drive(tr, 0, 0, 50, lapLength-25)
+	tr.Update(&fmtel.ForzaPacket{LapNumber: 1, LastLap: 20, DistanceTraveled: lapLength})
+	if tr.Best() != 0 {
+		t.Fatalf("best %v from a lap joined in progress", tr.Best())
+	}
+}
@@ -0,0 +1,340 @@
+package dyno
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Width of an rpm bin.
+const DefaultBinWidth = 250
+
+// Minimum throttle (0 to 255) for a sample to count as full throttle.
+const fullThrottle = 250
+
+// Highest slip ratio on a driven wheel that is not wheelspin.
+const maxSlipRatio = 1.0
+
+// Key identifies a car and tune. A tune change shows up as a new PI or drivetrain.
+type Key struct {
+	CarOrdinal int32 `json:"car_ordinal"`
+	PI         int32 `json:"pi"`
+	Drivetrain int32 `json:"drivetrain"`
+}
+
+// Returns the key of the car in the packet.
+func KeyOf(p *fmtel.ForzaPacket) Key {
+	return Key{CarOrdinal: p.CarOrdinal, PI: p.CarPerformanceIndex, Drivetrain: p.DrivetrainType}
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%d-%d-%d", k.CarOrdinal, k.PI, k.Drivetrain)
+}
+
+// Bin holds the highest power and torque seen in an rpm range.
+type Bin struct {
+	// Lowest rpm of the bin.
+	Rpm float32 `json:"rpm"`
+	// Power in watts.
+	Power float32 `json:"power"`
+	// Torque in newtonmeters.
+	Torque  float32 `json:"torque"`
+	Samples int     `json:"samples"`
+}
+
+// Curve is the power and torque curve of a car and tune.
+type Curve struct {
+	Key
+	MaxRpm   float32 `json:"max_rpm"`
+	BinWidth float32 `json:"bin_width"`
+	// Bins with samples, sorted by rpm.
+	Bins []Bin `json:"bins"`
+}
+
+// Returns true if the packet is a full-throttle sample without wheelspin.
+func FullThrottle(p *fmtel.ForzaPacket) bool {
+	if p.Accel < fullThrottle || p.Clutch > 0 || p.Gear == 0 || p.Power <= 0 || p.CurrentEngineRpm <= 0 {
+		return false
+	}
+	var slip []float32
+	switch p.DrivetrainType {
+	case 0:
+		slip = []float32{p.TireSlipRatioFrontLeft, p.TireSlipRatioFrontRight}
+	case 1:
+		slip = []float32{p.TireSlipRatioRearLeft, p.TireSlipRatioRearRight}
+	default:
+		slip = []float32{p.TireSlipRatioFrontLeft, p.TireSlipRatioFrontRight, p.TireSlipRatioRearLeft, p.TireSlipRatioRearRight}
+	}
+	for _, s := range slip {
+		if s > maxSlipRatio || s < -maxSlipRatio {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds a full-throttle sample to its rpm bin.
+func (c *Curve) Add(p *fmtel.ForzaPacket) {
+	if c.BinWidth <= 0 {
+		c.BinWidth = DefaultBinWidth
+	}
+	if p.EngineMaxRpm > 0 {
+		c.MaxRpm = p.EngineMaxRpm
+	}
+	rpm := float32(math.Floor(float64(p.CurrentEngineRpm/c.BinWidth))) * c.BinWidth
+	i := sort.Search(len(c.Bins), func(i int) bool { return c.Bins[i].Rpm >= rpm })
+	if i == len(c.Bins) || c.Bins[i].Rpm != rpm {
+		c.Bins = append(c.Bins, Bin{})
+		copy(c.Bins[i+1:], c.Bins[i:])
+		c.Bins[i] = Bin{Rpm: rpm}
+	}
+	b := &c.Bins[i]
+	b.Power = max(b.Power, p.Power)
+	b.Torque = max(b.Torque, p.Torque)
+	b.Samples++
+}
+
+// Returns the bins with the highest power and the highest torque.
+func (c *Curve) Peaks() (power, torque Bin) {
+	for _, b := range c.Bins {
+		if b.Power > power.Power {
+			power = b
+		}
+		if b.Torque > torque.Torque {
+			torque = b
+		}
+	}
+	return power, torque
+}
+
+// Returns the power and torque at an rpm, interpolated between bin centres.
+// Returns false outside the rpm range of the curve.
+func (c *Curve) At(rpm float32) (power, torque float32, ok bool) {
+	if len(c.Bins) == 0 {
+		return 0, 0, false
+	}
+	half := c.BinWidth / 2
+	first, last := c.Bins[0], c.Bins[len(c.Bins)-1]
+	if rpm < first.Rpm || rpm > last.Rpm+c.BinWidth {
+		return 0, 0, false
+	}
+	if rpm <= first.Rpm+half {
+		return first.Power, first.Torque, true
+	}
+	if rpm >= last.Rpm+half {
+		return last.Power, last.Torque, true
+	}
+
+	i := sort.Search(len(c.Bins), func(i int) bool { return c.Bins[i].Rpm+half >= rpm })
+	a, b := c.Bins[i-1], c.Bins[i]
+	f := (rpm - a.Rpm - half) / (b.Rpm - a.Rpm)
+	return a.Power + (b.Power-a.Power)*f, a.Torque + (b.Torque-a.Torque)*f, true
+}
+
+func (c *Curve) clone() *Curve {
+	out := *c
+	out.Bins = append([]Bin(nil), c.Bins...)
+	return &out
+}
+
+// Returns an error if a loaded curve could not have been built by Add.
+func (c *Curve) validate() error {
+	if !(c.BinWidth > 0) {
+		return fmt.Errorf("bin width %v is not positive", c.BinWidth)
+	}
+	for i := 1; i < len(c.Bins); i++ {
+		if c.Bins[i].Rpm <= c.Bins[i-1].Rpm {
+			return errors.New("bins are not sorted by rpm")
+		}
+	}
+	return nil
+}
+
+// Dyno collects full-throttle samples into a curve per car and tune.
+// It is safe for concurrent use.
+type Dyno struct {
+	mu      sync.Mutex
+	curves  map[Key]*Curve
+	dirty   map[Key]bool
+	current Key
+	hasCar  bool
+	pulling bool
+	added   bool
+}
+
+func New() *Dyno {
+	return &Dyno{curves: make(map[Key]*Curve), dirty: make(map[Key]bool)}
+}
+
+// Feeds a packet to the dyno. Returns true when a full-throttle pull that
+// added samples has ended, which is a good time to Save.
+// Only packets sent while a race is on should be passed in.
+func (d *Dyno) Update(p *fmtel.ForzaPacket) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := KeyOf(p)
+	d.current = key
+	d.hasCar = true
+
+	if !FullThrottle(p) {
+		ended := d.pulling && d.added
+		d.pulling, d.added = false, false
+		return ended
+	}
+
+	c, ok := d.curves[key]
+	if !ok {
+		c = &Curve{Key: key, BinWidth: DefaultBinWidth}
+		d.curves[key] = c
+	}
+	c.Add(p)
+	d.dirty[key] = true
+	d.pulling, d.added = true, true
+	return false
+}
+
+// Returns a copy of the curve of the current car and tune.
+func (d *Dyno) Current() (*Curve, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.hasCar {
+		return nil, false
+	}
+	c, ok := d.curves[d.current]
+	if !ok {
+		return nil, false
+	}
+	return c.clone(), true
+}
+
+// Returns copies of every curve of a car, sorted by PI.
+func (d *Dyno) Car(ordinal int32) []*Curve {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := []*Curve{}
+	for k, c := range d.curves {
+		if k.CarOrdinal == ordinal {
+			list = append(list, c.clone())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].PI != list[j].PI {
+			return list[i].PI < list[j].PI
+		}
+		return list[i].Drivetrain < list[j].Drivetrain
+	})
+	return list
+}
+
+// Loads every curve saved in dir. A missing directory is not an error.
+func (d *Dyno) Load(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		var c Curve
+		if err := json.Unmarshal(content, &c); err != nil {
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		d.curves[c.Key] = &c
+	}
+	return nil
+}
+
+// Writes the curves changed since the last save to dir, one file per car and tune.
+// The files are written without holding up Update.
+func (d *Dyno) Save(dir string) error {
+	d.mu.Lock()
+	files := make(map[Key][]byte, len(d.dirty))
+	for key := range d.dirty {
+		data, err := json.Marshal(d.curves[key])
+		if err != nil {
+			d.mu.Unlock()
+			return err
+		}
+		files[key] = data
+	}
+	clear(d.dirty)
+	d.mu.Unlock()
+	if len(files) == 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	for key, data := range files {
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, key.String()+".json"), data, 0o644)
+			if err == nil {
+				delete(files, key)
+			}
+		}
+	}
+	if err != nil {
+		// Try the unwritten curves again on the next save.
+		d.mu.Lock()
+		for key := range files {
+			d.dirty[key] = true
+		}
+		d.mu.Unlock()
+	}
+	return err
+}
+
+// Serves the curve of the current car and tune as JSON, or with ?car=<ordinal>
+// every curve of that car.
+func (d *Dyno) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body any
+	if car := r.URL.Query().Get("car"); car != "" {
+		ordinal, err := strconv.ParseInt(car, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid car", http.StatusBadRequest)
+			return
+		}
+		body = d.Car(int32(ordinal))
+	} else {
+		c, ok := d.Current()
+		if !ok {
+			http.Error(w, "no dyno data", http.StatusNotFound)
+			return
+		}
+		body = c
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,49 @@
+package dyno
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadValidatesCurves(t *testing.T) {
+	tests := map[string]string{
+		"zero bin width":     `{"car_ordinal": 1, "bin_width": 0, "bins": [{"rpm": 1000}, {"rpm": 1250}]}`,
+		"negative bin width": `{"car_ordinal": 1, "bin_width": -250, "bins": []}`,
+		"unsorted bins":      `{"car_ordinal": 1, "bin_width": 250, "bins": [{"rpm": 1250}, {"rpm": 1000}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "1-0-0.json"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := New().Load(dir); err == nil {
+				t.Fatal("invalid curve loaded")
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	d := New()
+	key := Key{CarOrdinal: 1, PI: 800, Drivetrain: 1}
+	d.curves[key] = &Curve{Key: key, BinWidth: DefaultBinWidth, Bins: []Bin{{Rpm: 1000, Power: 1}, {Rpm: 1250, Power: 2}}}
+	d.dirty[key] = true
+	if err := d.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.dirty) != 0 {
+		t.Fatal("saved curve still dirty")
+	}
+
+	loaded := New()
+	if err := loaded.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := loaded.curves[key]
+	if !ok || len(c.Bins) != 2 || c.Bins[1].Power != 2 {
+		t.Fatalf("got %+v", c)
+	}
+}
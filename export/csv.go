@@ -0,0 +1,55 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSVWriter writes a header row followed by one row per sample.
+// Columns the source did not carry are left empty.
+type CSVWriter struct {
+	w      *csv.Writer
+	record []string
+	header bool
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		w:      csv.NewWriter(w),
+		record: make([]string, len(Columns)),
+	}
+}
+
+func (c *CSVWriter) Write(s *Sample) error {
+	if !c.header {
+		c.header = true
+		for i, col := range Columns {
+			c.record[i] = col.Name
+		}
+		if err := c.w.Write(c.record); err != nil {
+			return err
+		}
+	}
+	for i, col := range Columns {
+		if !col.present(s) {
+			c.record[i] = ""
+			continue
+		}
+		v := col.value(s)
+		switch col.kind {
+		case intKind:
+			c.record[i] = strconv.FormatInt(int64(v), 10)
+		case float32Kind:
+			c.record[i] = strconv.FormatFloat(v, 'g', -1, 32)
+		default:
+			c.record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
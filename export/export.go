@@ -0,0 +1,131 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/recorder"
+)
+
+// Sample is a packet and its offset from the start of the session.
+type Sample struct {
+	Time   time.Duration
+	Packet decoder.Packet
+}
+
+// Writer writes one row per sample.
+type Writer interface {
+	Write(s *Sample) error
+	Close() error
+}
+
+type kind uint8
+
+const (
+	intKind kind = iota
+	float32Kind
+	float64Kind
+)
+
+// Column is a single exported value.
+type Column struct {
+	Name string
+	kind kind
+	// Fields the source must carry for the column to have a value.
+	// Zero for columns that always have one.
+	fields decoder.Fields
+	value  func(s *Sample) float64
+}
+
+// Returns true if the source of the sample carried the fields the column is worked out from.
+func (c *Column) present(s *Sample) bool {
+	return s.Packet.Has(c.fields)
+}
+
+// Every exported column in order: the session time, each ForzaPacket field and
+// values derived with the ForzaPacket helper methods. Writers leave a column
+// empty for samples whose source format does not carry it.
+var Columns = func() []Column {
+	columns := []Column{{
+		Name:  "Time",
+		kind:  float64Kind,
+		value: func(s *Sample) float64 { return s.Time.Seconds() },
+	}}
+
+	t := reflect.TypeOf(fmtel.ForzaPacket{})
+	for i := 0; i < t.NumField(); i++ {
+		i := i
+		k := intKind
+		if t.Field(i).Type.Kind() == reflect.Float32 {
+			k = float32Kind
+		}
+		fields, _ := decoder.FieldGroup(t.Field(i).Name)
+		columns = append(columns, Column{
+			Name:   t.Field(i).Name,
+			kind:   k,
+			fields: fields,
+			value: func(s *Sample) float64 {
+				v := reflect.ValueOf(&s.Packet.ForzaPacket).Elem().Field(i)
+				switch v.Kind() {
+				case reflect.Float32:
+					return v.Float()
+				case reflect.Int8, reflect.Int16, reflect.Int32:
+					return float64(v.Int())
+				default:
+					return float64(v.Uint())
+				}
+			},
+		})
+	}
+
+	// Every derived value is worked out from dash fields.
+	derived := func(name string, k kind, value func(p *fmtel.ForzaPacket) float64) {
+		columns = append(columns, Column{
+			Name:   name,
+			kind:   k,
+			fields: decoder.DashFields,
+			value:  func(s *Sample) float64 { return value(&s.Packet.ForzaPacket) },
+		})
+	}
+	derived("KmPerHour", intKind, func(p *fmtel.ForzaPacket) float64 { return float64(p.KmPerHour()) })
+	derived("MilesPerHour", intKind, func(p *fmtel.ForzaPacket) float64 { return float64(p.MilesPerHour()) })
+	derived("HorsePower", intKind, func(p *fmtel.ForzaPacket) float64 { return float64(p.HorsePower()) })
+	derived("Kilowatts", intKind, func(p *fmtel.ForzaPacket) float64 { return float64(p.Kilowatts()) })
+	derived("FootPounds", intKind, func(p *fmtel.ForzaPacket) float64 { return float64(p.FootPounds()) })
+	derived("TireTempFrontLeftCelsius", float32Kind, func(p *fmtel.ForzaPacket) float64 { return float64(p.TireTempsInCelsius().FrontLeft) })
+	derived("TireTempFrontRightCelsius", float32Kind, func(p *fmtel.ForzaPacket) float64 { return float64(p.TireTempsInCelsius().FrontRight) })
+	derived("TireTempRearLeftCelsius", float32Kind, func(p *fmtel.ForzaPacket) float64 { return float64(p.TireTempsInCelsius().RearLeft) })
+	derived("TireTempRearRightCelsius", float32Kind, func(p *fmtel.ForzaPacket) float64 { return float64(p.TireTempsInCelsius().RearRight) })
+	return columns
+}()
+
+// Writes every decodable packet of a recording. Packets not sent during a race are
+// skipped if raceOnly is set. Returns the number of rows written.
+func FromRecording(r *recorder.Reader, w Writer, raceOnly bool) (int, error) {
+	n := 0
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		packet, err := r.Decode(rec)
+		if err != nil {
+			continue
+		}
+		if raceOnly && packet.IsRaceOn != 1 {
+			continue
+		}
+		s := Sample{Time: rec.Offset(&r.Header), Packet: packet}
+		if err := w.Write(&s); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// Returns a sample from a full Motorsport packet and one from a sled only packet.
+func samples() []Sample {
+	full := decoder.Packet{Format: decoder.Motorsport, Fields: decoder.Motorsport.Fields()}
+	full.Speed = 50
+	full.EngineMaxRpm = 8000
+	full.PositionX = -12.5
+	full.LapNumber = 3
+	full.TireWearFrontLeft = 0.25
+	full.TrackOrdinal = 110
+
+	sled := decoder.Packet{Format: decoder.Sled, Fields: decoder.Sled.Fields()}
+	sled.EngineMaxRpm = 7000
+	sled.AccelerationX = 9.5
+
+	return []Sample{
+		{Time: 1500 * time.Millisecond, Packet: full},
+		{Time: 2 * time.Second, Packet: sled},
+	}
+}
+
+func column(t *testing.T, name string) int {
+	t.Helper()
+	for i, col := range Columns {
+		if col.Name == name {
+			return i
+		}
+	}
+	t.Fatalf("no column %s", name)
+	return 0
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+	for _, s := range samples() {
+		if err := w.Write(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 rows", len(records))
+	}
+	for i, col := range Columns {
+		if records[0][i] != col.Name {
+			t.Fatalf("header %d is %q, want %q", i, records[0][i], col.Name)
+		}
+	}
+
+	tests := []struct {
+		row    int
+		column string
+		want   string
+	}{
+		{1, "Time", "1.5"},
+		{1, "Speed", "50"},
+		{1, "EngineMaxRpm", "8000"},
+		{1, "PositionX", "-12.5"},
+		{1, "LapNumber", "3"},
+		{1, "TireWearFrontLeft", "0.25"},
+		{1, "TrackOrdinal", "110"},
+		{1, "KmPerHour", "180"},
+		{2, "Time", "2"},
+		{2, "EngineMaxRpm", "7000"},
+		{2, "AccelerationX", "9.5"},
+		// Not carried by the sled format.
+		{2, "Speed", ""},
+		{2, "KmPerHour", ""},
+		{2, "PositionX", ""},
+		{2, "LapNumber", ""},
+		{2, "TireWearFrontLeft", ""},
+		{2, "TrackOrdinal", ""},
+	}
+	for _, tt := range tests {
+		if got := records[tt.row][column(t, tt.column)]; got != tt.want {
+			t.Errorf("row %d %s = %q, want %q", tt.row, tt.column, got, tt.want)
+		}
+	}
+
+	// Every value the format carries parses back.
+	for i, v := range records[1] {
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			t.Errorf("%s = %q: %v", Columns[i].Name, v, err)
+		}
+	}
+}
+
+func TestParquetSchema(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewParquetWriter(&buf)
+	for _, s := range samples() {
+		if err := w.Write(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := f.Schema()
+	if n := len(schema.Fields()); n != len(Columns) {
+		t.Fatalf("got %d columns, want %d", n, len(Columns))
+	}
+	for _, col := range Columns {
+		leaf, ok := schema.Lookup(col.Name)
+		if !ok {
+			t.Fatalf("no column %s", col.Name)
+		}
+		if optional := leaf.Node.Optional(); optional != (col.Name != "Time") {
+			t.Errorf("%s optional = %v", col.Name, optional)
+		}
+	}
+
+	rows := make([]parquet.Row, 2)
+	r := parquet.NewReader(f)
+	n, err := r.ReadRows(rows)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("got %d rows, want 2", n)
+	}
+	value := func(row int, name string) parquet.Value {
+		leaf, _ := schema.Lookup(name)
+		return rows[row][leaf.ColumnIndex]
+	}
+	if v := value(0, "Time").Double(); v != 1.5 {
+		t.Errorf("Time = %v, want 1.5", v)
+	}
+	if v := value(0, "PositionX"); v.IsNull() || v.Float() != -12.5 {
+		t.Errorf("PositionX = %v, want -12.5", v)
+	}
+	if v := value(1, "AccelerationX"); v.IsNull() || v.Float() != 9.5 {
+		t.Errorf("AccelerationX = %v, want 9.5", v)
+	}
+	for _, name := range []string{"Speed", "KmPerHour", "LapNumber", "TrackOrdinal"} {
+		if v := value(1, name); !v.IsNull() {
+			t.Errorf("%s = %v for a sled packet, want null", name, v)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// Rows buffered before they are written to the file.
+const parquetBatch = 1024
+
+// ParquetWriter writes samples as a zstd compressed Parquet file with one column per Column.
+// Columns the source may not carry are optional and null when missing.
+type ParquetWriter struct {
+	w       *parquet.Writer
+	indexes []int
+	rows    []parquet.Row
+}
+
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	group := make(parquet.Group, len(Columns))
+	for _, col := range Columns {
+		var node parquet.Node
+		switch col.kind {
+		case intKind:
+			node = parquet.Int(64)
+		case float32Kind:
+			node = parquet.Leaf(parquet.FloatType)
+		default:
+			node = parquet.Leaf(parquet.DoubleType)
+		}
+		if col.fields != 0 {
+			node = parquet.Optional(node)
+		}
+		group[col.Name] = parquet.Compressed(node, &zstd.Codec{})
+	}
+	schema := parquet.NewSchema("telemetry", group)
+
+	// Group fields are stored sorted by name, so map each column to its leaf index.
+	indexes := make([]int, len(Columns))
+	for i, col := range Columns {
+		leaf, _ := schema.Lookup(col.Name)
+		indexes[i] = leaf.ColumnIndex
+	}
+
+	return &ParquetWriter{
+		w:       parquet.NewWriter(w, schema),
+		indexes: indexes,
+		rows:    make([]parquet.Row, 0, parquetBatch),
+	}
+}
+
+func (p *ParquetWriter) Write(s *Sample) error {
+	row := make(parquet.Row, len(Columns))
+	for i, col := range Columns {
+		if !col.present(s) {
+			row[p.indexes[i]] = parquet.NullValue().Level(0, 0, p.indexes[i])
+			continue
+		}
+		v := col.value(s)
+		var value parquet.Value
+		switch col.kind {
+		case intKind:
+			value = parquet.Int64Value(int64(v))
+		case float32Kind:
+			value = parquet.FloatValue(float32(v))
+		default:
+			value = parquet.DoubleValue(v)
+		}
+		definition := 0
+		if col.fields != 0 {
+			definition = 1
+		}
+		row[p.indexes[i]] = value.Level(0, definition, p.indexes[i])
+	}
+	p.rows = append(p.rows, row)
+	if len(p.rows) == cap(p.rows) {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *ParquetWriter) flush() error {
+	_, err := p.w.WriteRows(p.rows)
+	p.rows = p.rows[:0]
+	return err
+}
+
+func (p *ParquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
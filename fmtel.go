@@ -154,6 +154,22 @@ type TireTemperatures struct {
 	RearRight  float32
 }
 
+type TireWear struct {
+	FrontLeft  float32
+	FrontRight float32
+	RearLeft   float32
+	RearRight  float32
+}
+
+// Acceleration in g along the car's axes.
+type GForce struct {
+	// Positive to the right.
+	Lateral float32
+	// Positive when accelerating, negative when braking.
+	Longitudinal float32
+	Vertical     float32
+}
+
 type PedalInputs struct {
 	Clutch   uint
 	Brake    uint
@@ -206,6 +222,30 @@ func (m *ForzaPacket) TireTempsInCelsius() *TireTemperatures {
 	return &b
 }
 
+// Standard gravity in m/s².
+const Gravity = 9.80665
+
+// Returns the car-local acceleration in g.
+func (m *ForzaPacket) GForce() *GForce {
+	b := GForce{
+		m.AccelerationX / Gravity,
+		m.AccelerationZ / Gravity,
+		m.AccelerationY / Gravity,
+	}
+	return &b
+}
+
+// Returns current tire wear for each corner.
+func (m *ForzaPacket) TireWear() *TireWear {
+	b := TireWear{
+		m.TireWearFrontLeft,
+		m.TireWearFrontRight,
+		m.TireWearRearLeft,
+		m.TireWearRearRight,
+	}
+	return &b
+}
+
 // Returns the current cars drivetrain type as a label ( FWD , RWD , AWD ).
 // If the type cannot be parsed it returns "-".
 func (m *ForzaPacket) ParsedDrivetrainType() string {
@@ -0,0 +1,86 @@
+package forward
+
+import (
+	"net"
+
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// Forwarder re-sends datagrams to several UDP targets, so more than one
+// application can consume the single telemetry stream the game sends.
+type Forwarder struct {
+	conns []net.Conn
+	// Layout of incoming datagrams. Auto detects it from the datagram length.
+	Source decoder.Format
+	// Layout to convert datagrams to before sending. Auto sends them unchanged.
+	Target decoder.Format
+	// Only datagrams whose packet passes Filter are forwarded. Nil forwards everything.
+	// Datagrams that cannot be decoded cannot be filtered and are forwarded.
+	Filter func(p *decoder.Packet) bool
+	// Called with every decode, conversion or send error.
+	OnError func(err error)
+	buf     []byte
+}
+
+// Dials every target address ("host:port").
+func New(targets []string) (*Forwarder, error) {
+	f := &Forwarder{}
+	for _, target := range targets {
+		conn, err := net.Dial("udp", target)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.conns = append(f.conns, conn)
+	}
+	return f, nil
+}
+
+// Only forwards packets sent while a race is on.
+func RaceOnly(p *decoder.Packet) bool {
+	return p.IsRaceOn == 1
+}
+
+func (f *Forwarder) report(err error) {
+	if f.OnError != nil {
+		f.OnError(err)
+	}
+}
+
+// Sends a datagram to every target. Suitable as server.Listener.OnDatagram.
+func (f *Forwarder) Forward(b []byte) {
+	if f.Filter != nil {
+		packet, err := decoder.Decode(b, f.Source)
+		if err == nil && !f.Filter(&packet) {
+			return
+		}
+	}
+
+	out := b
+	if f.Target != decoder.Auto {
+		var err error
+		f.buf, err = decoder.AppendConverted(f.buf[:0], b, f.Source, f.Target)
+		if err != nil {
+			f.report(err)
+			return
+		}
+		out = f.buf
+	}
+
+	for _, conn := range f.conns {
+		_, err := conn.Write(out)
+		if err != nil {
+			f.report(err)
+		}
+	}
+}
+
+func (f *Forwarder) Close() error {
+	var err error
+	for _, conn := range f.conns {
+		if cerr := conn.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
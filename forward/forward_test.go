@@ -0,0 +1,120 @@
+package forward
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newForwarder(t *testing.T, targets ...net.PacketConn) *Forwarder {
+	t.Helper()
+	var addrs []string
+	for _, target := range targets {
+		addrs = append(addrs, target.LocalAddr().String())
+	}
+	f, err := New(addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func receive(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b[:n]
+}
+
+func datagram(timestamp uint32, raceOn int32) []byte {
+	p := fmtel.ForzaPacket{IsRaceOn: raceOn, TimestampMS: timestamp, Speed: 42}
+	b, _ := p.MarshalBinary()
+	return b
+}
+
+func TestForwardToEveryTarget(t *testing.T) {
+	a, b := listenUDP(t), listenUDP(t)
+	f := newForwarder(t, a, b)
+
+	want := datagram(1, 1)
+	f.Forward(want)
+	for _, conn := range []net.PacketConn{a, b} {
+		if got := receive(t, conn); !bytes.Equal(got, want) {
+			t.Errorf("%s got %d bytes, want the datagram unchanged", conn.LocalAddr(), len(got))
+		}
+	}
+}
+
+func TestForwardFilter(t *testing.T) {
+	conn := listenUDP(t)
+	f := newForwarder(t, conn)
+	f.Filter = RaceOnly
+
+	f.Forward(datagram(1, 0))
+	f.Forward(datagram(2, 1))
+	// Undecodable datagrams cannot be filtered and are passed on.
+	f.Forward([]byte{1, 2, 3})
+
+	p, err := decoder.Decode(receive(t, conn), decoder.Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TimestampMS != 2 {
+		t.Errorf("got packet %d first, want 2", p.TimestampMS)
+	}
+	if got := receive(t, conn); !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("got %v, want the undecodable datagram", got)
+	}
+}
+
+func TestForwardConverts(t *testing.T) {
+	conn := listenUDP(t)
+	f := newForwarder(t, conn)
+	f.Target = decoder.Sled
+
+	f.Forward(datagram(7, 1))
+	b := receive(t, conn)
+	if len(b) != decoder.SledSize {
+		t.Fatalf("got %d bytes, want %d", len(b), decoder.SledSize)
+	}
+	p, err := decoder.Decode(b, decoder.Sled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TimestampMS != 7 || p.IsRaceOn != 1 {
+		t.Errorf("got timestamp %d race on %d, want 7 and 1", p.TimestampMS, p.IsRaceOn)
+	}
+}
+
+func TestForwardReportsConversionErrors(t *testing.T) {
+	conn := listenUDP(t)
+	f := newForwarder(t, conn)
+	f.Target = decoder.Motorsport
+	var errs []error
+	f.OnError = func(err error) { errs = append(errs, err) }
+
+	// A sled datagram lacks the fields of the Motorsport layout.
+	f.Forward(make([]byte, decoder.SledSize))
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+}
@@ -0,0 +1,201 @@
+package gearing
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Minimum speed in m/s for a sample.
+const minSpeed = 5.0
+
+// Highest slip ratio on any wheel for a sample.
+const maxSlipRatio = 0.1
+
+// Highest forward gear. Forza reports reverse as 0.
+const maxGear = 10
+
+// Radians per second per rpm.
+const radPerRpm = 2 * math.Pi / 60
+
+// Gear is the estimated gearing of one gear.
+type Gear struct {
+	Gear int `json:"gear"`
+	// Engine revolutions per driven wheel revolution, final drive included.
+	Ratio float32 `json:"ratio"`
+	// Ratio divided by the ratio of the gear below, 0 if that gear has no ratio yet.
+	Spacing float32 `json:"spacing"`
+	// Speed in m/s at the redline, 0 until the tire radius is known.
+	TopSpeed float32 `json:"top_speed"`
+	Samples  int     `json:"samples"`
+}
+
+// Report is the estimated gearing of the current car.
+type Report struct {
+	Gears []Gear `json:"gears"`
+	// Tire radius in meters, 0 until known.
+	TireRadius float32 `json:"tire_radius"`
+	Redline    float32 `json:"redline"`
+}
+
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (m *mean) add(v float64) {
+	m.sum += v
+	m.n++
+}
+
+func (m mean) value() float32 {
+	if m.n == 0 {
+		return 0
+	}
+	return float32(m.sum / float64(m.n))
+}
+
+// Analyzer estimates the overall ratio of every gear and the tire radius from
+// engine rpm, wheel speeds and speed while driving without wheelspin. It
+// starts over when the car or tune changes. It is safe for concurrent use.
+type Analyzer struct {
+	mu      sync.Mutex
+	ratios  map[int]*mean
+	radius  mean
+	redline float32
+	last    fmtel.ForzaPacket
+	hasLast bool
+}
+
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{ratios: make(map[int]*mean)}
+}
+
+// Returns the average rotation speed of the driven wheels in rad/s.
+func drivenWheelSpeed(p *fmtel.ForzaPacket) float32 {
+	switch p.DrivetrainType {
+	case 0:
+		return (p.WheelRotationSpeedFrontLeft + p.WheelRotationSpeedFrontRight) / 2
+	case 1:
+		return (p.WheelRotationSpeedRearLeft + p.WheelRotationSpeedRearRight) / 2
+	default:
+		return (p.WheelRotationSpeedFrontLeft + p.WheelRotationSpeedFrontRight +
+			p.WheelRotationSpeedRearLeft + p.WheelRotationSpeedRearRight) / 4
+	}
+}
+
+// Returns true if the packet is a sample of a gear in steady drive: clutch
+// out, the same gear as the previous packet and no wheel slipping.
+func steady(prev, p *fmtel.ForzaPacket) bool {
+	if p.Gear == 0 || p.Gear > maxGear || p.Gear != prev.Gear || p.Clutch > 0 ||
+		p.Speed < minSpeed || p.CurrentEngineRpm <= 0 {
+		return false
+	}
+	for _, s := range []float32{p.TireSlipRatioFrontLeft, p.TireSlipRatioFrontRight, p.TireSlipRatioRearLeft, p.TireSlipRatioRearRight} {
+		if s > maxSlipRatio || s < -maxSlipRatio {
+			return false
+		}
+	}
+	return true
+}
+
+// Feeds a packet to the analyzer.
+// Only packets sent while a race is on should be passed in.
+func (a *Analyzer) Update(p *fmtel.ForzaPacket) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.hasLast && (p.CarOrdinal != a.last.CarOrdinal ||
+		p.CarPerformanceIndex != a.last.CarPerformanceIndex ||
+		p.DrivetrainType != a.last.DrivetrainType) {
+		a.reset()
+	}
+	prev := a.last
+	a.last = *p
+	a.redline = p.EngineMaxRpm
+	if !a.hasLast {
+		a.hasLast = true
+		return
+	}
+	if !steady(&prev, p) {
+		return
+	}
+
+	wheel := drivenWheelSpeed(p)
+	if wheel <= 0 {
+		return
+	}
+	m, ok := a.ratios[int(p.Gear)]
+	if !ok {
+		m = &mean{}
+		a.ratios[int(p.Gear)] = m
+	}
+	m.add(float64(p.CurrentEngineRpm * radPerRpm / wheel))
+
+	all := (p.WheelRotationSpeedFrontLeft + p.WheelRotationSpeedFrontRight +
+		p.WheelRotationSpeedRearLeft + p.WheelRotationSpeedRearRight) / 4
+	if all > 0 {
+		a.radius.add(float64(p.Speed / all))
+	}
+}
+
+// Returns the overall ratio of every gear seen.
+func (a *Analyzer) Ratios() map[int]float32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make(map[int]float32, len(a.ratios))
+	for g, m := range a.ratios {
+		out[g] = m.value()
+	}
+	return out
+}
+
+// Returns the tire radius in meters.
+func (a *Analyzer) TireRadius() (float32, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.radius.value(), a.radius.n > 0
+}
+
+// Returns the gearing of every gear seen, lowest first.
+func (a *Analyzer) Report() Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r := Report{TireRadius: a.radius.value(), Redline: a.redline}
+	for g, m := range a.ratios {
+		gear := Gear{Gear: g, Ratio: m.value(), Samples: m.n}
+		if below, ok := a.ratios[g-1]; ok {
+			gear.Spacing = gear.Ratio / below.value()
+		}
+		gear.TopSpeed = TopSpeed(gear.Ratio, r.TireRadius, r.Redline)
+		r.Gears = append(r.Gears, gear)
+	}
+	sort.Slice(r.Gears, func(i, j int) bool {
+		return r.Gears[i].Gear < r.Gears[j].Gear
+	})
+	return r
+}
+
+// Returns the speed in m/s at rpm for an overall ratio and tire radius in meters.
+func TopSpeed(ratio, radius, rpm float32) float32 {
+	if ratio <= 0 {
+		return 0
+	}
+	return rpm * radPerRpm / ratio * radius
+}
+
+func (a *Analyzer) reset() {
+	clear(a.ratios)
+	a.radius = mean{}
+}
+
+// Forgets everything learned.
+func (a *Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reset()
+	a.hasLast = false
+}
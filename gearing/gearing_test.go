@@ -0,0 +1,103 @@
+package gearing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+const radius = 0.3
+
+// Returns a packet of a rear wheel drive car in gear turning rpm through an overall ratio.
+func drive(gear uint8, ratio, rpm float32) fmtel.ForzaPacket {
+	wheel := rpm * radPerRpm / ratio
+	return fmtel.ForzaPacket{
+		CarOrdinal:                   1,
+		DrivetrainType:               1,
+		EngineMaxRpm:                 8000,
+		Gear:                         gear,
+		CurrentEngineRpm:             rpm,
+		Speed:                        wheel * radius,
+		WheelRotationSpeedFrontLeft:  wheel,
+		WheelRotationSpeedFrontRight: wheel,
+		WheelRotationSpeedRearLeft:   wheel,
+		WheelRotationSpeedRearRight:  wheel,
+	}
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestRatioEstimate(t *testing.T) {
+	a := NewAnalyzer()
+	ratios := map[uint8]float32{2: 10, 3: 7.5}
+	for gear, ratio := range ratios {
+		for rpm := float32(4000); rpm <= 6000; rpm += 500 {
+			p := drive(gear, ratio, rpm)
+			a.Update(&p)
+		}
+	}
+
+	r := a.Report()
+	if len(r.Gears) != 2 {
+		t.Fatalf("got %d gears, want 2", len(r.Gears))
+	}
+	for _, g := range r.Gears {
+		if want := ratios[uint8(g.Gear)]; !near(g.Ratio, want) {
+			t.Errorf("gear %d ratio %v, want %v", g.Gear, g.Ratio, want)
+		}
+	}
+	if !near(r.TireRadius, radius) {
+		t.Errorf("tire radius %v, want %v", r.TireRadius, radius)
+	}
+	if third := r.Gears[1]; !near(third.Spacing, 0.75) {
+		t.Errorf("third gear spacing %v, want 0.75", third.Spacing)
+	}
+	// 8000 rpm through 7.5 on a 0.3m tire.
+	if third := r.Gears[1]; !near(third.TopSpeed, 8000*radPerRpm/7.5*radius) {
+		t.Errorf("third gear top speed %v", third.TopSpeed)
+	}
+}
+
+func TestSkipsUnsteadySamples(t *testing.T) {
+	tests := map[string]func(p *fmtel.ForzaPacket){
+		"clutch in":   func(p *fmtel.ForzaPacket) { p.Clutch = 255 },
+		"wheelspin":   func(p *fmtel.ForzaPacket) { p.TireSlipRatioRearLeft = 0.5 },
+		"too slow":    func(p *fmtel.ForzaPacket) { p.Speed = 1 },
+		"neutral":     func(p *fmtel.ForzaPacket) { p.Gear = 0 },
+		"gear change": func(p *fmtel.ForzaPacket) { p.Gear = 4 },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := NewAnalyzer()
+			first := drive(3, 7.5, 5000)
+			a.Update(&first)
+			p := drive(3, 7.5, 5000)
+			change(&p)
+			a.Update(&p)
+			if r := a.Ratios(); len(r) != 0 {
+				t.Fatalf("got ratios %v", r)
+			}
+		})
+	}
+}
+
+func TestCarChangeStartsOver(t *testing.T) {
+	a := NewAnalyzer()
+	for i := 0; i < 3; i++ {
+		p := drive(3, 7.5, 5000)
+		a.Update(&p)
+	}
+	// The new car has a shorter third gear.
+	for i := 0; i < 3; i++ {
+		p := drive(3, 9, 5000)
+		p.CarOrdinal = 2
+		a.Update(&p)
+	}
+	r := a.Report()
+	if len(r.Gears) != 1 || r.Gears[0].Samples != 3 || !near(r.Gears[0].Ratio, 9) {
+		t.Fatalf("got %+v, want only the new car", r.Gears)
+	}
+}
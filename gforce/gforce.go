@@ -0,0 +1,79 @@
+package gforce
+
+import (
+	"math"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Default span in milliseconds the trail covers.
+const DefaultTrailSpan = 2000
+
+// Peaks are the highest g seen in each direction, as positive values.
+type Peaks struct {
+	Left         float32
+	Right        float32
+	Braking      float32
+	Acceleration float32
+}
+
+type sample struct {
+	at uint32
+	g  fmtel.GForce
+}
+
+// Trail keeps the recent g-forces for a friction circle and the session peaks.
+type Trail struct {
+	// Span in milliseconds the trail covers.
+	Span    uint32
+	samples []sample
+	peaks   Peaks
+}
+
+func NewTrail() *Trail {
+	return &Trail{Span: DefaultTrailSpan}
+}
+
+// Feeds a packet to the trail. Only packets sent while a race is on should be passed in.
+func (t *Trail) Update(p *fmtel.ForzaPacket) {
+	g := *p.GForce()
+	if n := len(t.samples); n > 0 && p.TimestampMS-t.samples[n-1].at > 10*t.Span {
+		t.samples = t.samples[:0]
+	}
+	t.samples = append(t.samples, sample{at: p.TimestampMS, g: g})
+
+	drop := 0
+	for drop < len(t.samples)-1 && p.TimestampMS-t.samples[drop].at > t.Span {
+		drop++
+	}
+	t.samples = t.samples[drop:]
+
+	t.peaks.Right = max(t.peaks.Right, g.Lateral)
+	t.peaks.Left = max(t.peaks.Left, -g.Lateral)
+	t.peaks.Acceleration = max(t.peaks.Acceleration, g.Longitudinal)
+	t.peaks.Braking = max(t.peaks.Braking, -g.Longitudinal)
+}
+
+// Returns the g-forces within the span, oldest first.
+func (t *Trail) Points() []fmtel.GForce {
+	points := make([]fmtel.GForce, len(t.samples))
+	for i, s := range t.samples {
+		points[i] = s.g
+	}
+	return points
+}
+
+// Returns the peaks since the last reset.
+func (t *Trail) Peaks() Peaks {
+	return t.peaks
+}
+
+func (t *Trail) Reset() {
+	t.samples = nil
+	t.peaks = Peaks{}
+}
+
+// Returns the combined horizontal g.
+func Combined(g fmtel.GForce) float32 {
+	return float32(math.Hypot(float64(g.Lateral), float64(g.Longitudinal)))
+}
@@ -0,0 +1,63 @@
+package gforce
+
+import (
+	"testing"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Returns a packet at ts (ms) with lateral and longitudinal g.
+func pk(ts uint32, lateral, longitudinal float32) fmtel.ForzaPacket {
+	return fmtel.ForzaPacket{
+		TimestampMS:   ts,
+		AccelerationX: lateral * fmtel.Gravity,
+		AccelerationZ: longitudinal * fmtel.Gravity,
+	}
+}
+
+func TestTrailSpan(t *testing.T) {
+	trail := &Trail{Span: 1000}
+	for ts := uint32(0); ts <= 3000; ts += 500 {
+		p := pk(ts, float32(ts)/1000, 0)
+		trail.Update(&p)
+	}
+	points := trail.Points()
+	if len(points) != 3 {
+		t.Fatalf("got %d points, want the last second", len(points))
+	}
+	for i, want := range []float32{2, 2.5, 3} {
+		if points[i].Lateral != want {
+			t.Errorf("point %d lateral %v, want %v", i, points[i].Lateral, want)
+		}
+	}
+
+	// After a long gap, such as a pause, only the new sample is kept.
+	p := pk(60000, 0, 1)
+	trail.Update(&p)
+	if points := trail.Points(); len(points) != 1 || points[0].Longitudinal != 1 {
+		t.Fatalf("got %v after a gap, want only the new sample", points)
+	}
+}
+
+func TestPeaks(t *testing.T) {
+	trail := NewTrail()
+	for i, g := range [][2]float32{{1.5, 0}, {-2, 0}, {0, 0.5}, {0, -1.25}, {0.5, 0.25}} {
+		p := pk(uint32(i)*100, g[0], g[1])
+		trail.Update(&p)
+	}
+	want := Peaks{Left: 2, Right: 1.5, Braking: 1.25, Acceleration: 0.5}
+	if got := trail.Peaks(); got != want {
+		t.Fatalf("got peaks %+v, want %+v", got, want)
+	}
+
+	trail.Reset()
+	if got := trail.Peaks(); got != (Peaks{}) || len(trail.Points()) != 0 {
+		t.Fatalf("got peaks %+v and %d points after a reset", got, len(trail.Points()))
+	}
+}
+
+func TestCombined(t *testing.T) {
+	if got := Combined(fmtel.GForce{Lateral: 3, Longitudinal: -4}); got != 5 {
+		t.Fatalf("got %v, want 5", got)
+	}
+}
@@ -4,26 +4,34 @@ go 1.21.3
 
 require (
 	atomicgo.dev/cursor v0.2.0
+	github.com/BurntSushi/toml v1.3.2
 	github.com/charmbracelet/log v0.2.5
+	github.com/gookit/color v1.5.4
 	github.com/guptarohit/asciigraph v0.5.6
+	github.com/klauspost/compress v1.17.9
 	github.com/pterm/pterm v0.12.69
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/term v0.13.0
 )
 
 require (
 	atomicgo.dev/schedule v0.1.0 // indirect
 	github.com/GeertJohan/go.rice v1.0.3 // indirect
+	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/azer/debug v0.0.0-20141116004914-4769e572857f // indirect
 	github.com/azer/go-style v0.0.0-20130627093536-14e31c5abbe5 // indirect
 	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
 	github.com/containerd/console v1.0.3 // indirect
 	github.com/daaku/go.zipexe v1.0.2 // indirect
-	github.com/gookit/color v1.5.4 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/lithammer/fuzzysearch v1.1.8 // indirect
+	github.com/olekukonko/tablewriter v0.0.5 // indirect
+	github.com/parquet-go/parquet-go v0.23.0
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	github.com/segmentio/encoding v0.4.0 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1 // indirect
 	golang.org/x/net v0.17.0 // indirect
-	golang.org/x/term v0.13.0 // indirect
 	golang.org/x/text v0.13.0 // indirect
 	gopkg.in/cenkalti/backoff.v1 v1.1.0 // indirect
 )
@@ -37,14 +45,14 @@ require (
 	github.com/gorilla/websocket v1.5.0
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/mattn/go-runewidth v0.0.15
 	github.com/mroth/sseserver v1.1.2
 	github.com/muesli/reflow v0.3.0 // indirect
 	github.com/muesli/termenv v0.15.2
 	github.com/r3labs/sse/v2 v2.10.0
-	github.com/rivo/uniseg v0.4.4 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/tmaxmax/go-sse v0.6.0
-	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 )
 
 replace github.com/stelmanjones/fmtel => ../fmtel
@@ -0,0 +1,174 @@
+package hub
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// What a subscription does with a packet when its buffer is full.
+type DropPolicy uint8
+
+const (
+	// Discard the incoming packet.
+	DropNewest DropPolicy = iota
+	// Discard the oldest buffered packet to make room.
+	DropOldest
+	// Wait until the subscriber makes room. Holds up every other subscriber.
+	Block
+)
+
+func DropPolicyFromString(s string) DropPolicy {
+	switch s {
+	case "oldest":
+		return DropOldest
+	case "block":
+		return Block
+	default:
+		return DropNewest
+	}
+}
+
+// Subscription receives every packet published after it was created, subject to its drop policy.
+// Each subscriber gets its own copy of a packet.
+type Subscription struct {
+	// Closed when the subscription or the hub is closed.
+	C <-chan decoder.Packet
+
+	ch      chan decoder.Packet
+	policy  DropPolicy
+	hub     *Hub
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Returns the number of packets dropped for this subscriber.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Stops delivery and closes C. Safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+		if _, ok := s.hub.subs[s]; ok {
+			delete(s.hub.subs, s)
+			close(s.ch)
+		}
+	})
+}
+
+func (s *Subscription) deliver(p decoder.Packet) {
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- p:
+		case <-s.done:
+		case <-s.hub.done:
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- p:
+				return
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- p:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Hub fans published packets out to any number of subscribers.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	latest atomic.Pointer[decoder.Packet]
+	closed bool
+	// Closed by Close so a publish held up by a Block subscriber gives up.
+	done chan struct{}
+	once sync.Once
+}
+
+func New() *Hub {
+	return &Hub{
+		subs: make(map[*Subscription]struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Subscribes with a buffer of size packets. Sizes below 1 are raised to 1.
+func (h *Hub) Subscribe(size int, policy DropPolicy) *Subscription {
+	if size < 1 {
+		size = 1
+	}
+	ch := make(chan decoder.Packet, size)
+	s := &Subscription{
+		C:      ch,
+		ch:     ch,
+		policy: policy,
+		hub:    h,
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// Sends a packet to every subscriber and makes it the latest packet.
+func (h *Hub) Publish(p decoder.Packet) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest.Store(&p)
+	for s := range h.subs {
+		s.deliver(p)
+	}
+}
+
+// Returns a copy of the most recently published packet.
+func (h *Hub) Latest() (decoder.Packet, bool) {
+	p := h.latest.Load()
+	if p == nil {
+		return decoder.Packet{ForzaPacket: fmtel.DefaultForzaPacket}, false
+	}
+	return *p, true
+}
+
+// Returns the number of active subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Closes every subscription. Later subscriptions are closed immediately.
+func (h *Hub) Close() {
+	h.once.Do(func() { close(h.done) })
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.ch)
+	}
+}
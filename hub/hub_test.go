@@ -0,0 +1,182 @@
+package hub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+func packet(ts uint32) decoder.Packet {
+	return decoder.Packet{ForzaPacket: fmtel.ForzaPacket{TimestampMS: ts}}
+}
+
+// Returns the timestamps of every packet buffered in s without blocking.
+func drain(s *Subscription) []uint32 {
+	var got []uint32
+	for {
+		select {
+		case p, ok := <-s.C:
+			if !ok {
+				return got
+			}
+			got = append(got, p.TimestampMS)
+		default:
+			return got
+		}
+	}
+}
+
+func TestDropNewest(t *testing.T) {
+	h := New()
+	s := h.Subscribe(2, DropNewest)
+	for i := uint32(1); i <= 5; i++ {
+		h.Publish(packet(i))
+	}
+	got := drain(s)
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+	if s.Dropped() != 3 {
+		t.Fatalf("dropped %d, want 3", s.Dropped())
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	h := New()
+	s := h.Subscribe(2, DropOldest)
+	for i := uint32(1); i <= 5; i++ {
+		h.Publish(packet(i))
+	}
+	got := drain(s)
+	if len(got) != 2 || got[0] != 4 || got[1] != 5 {
+		t.Fatalf("got %v, want [4 5]", got)
+	}
+	if s.Dropped() != 3 {
+		t.Fatalf("dropped %d, want 3", s.Dropped())
+	}
+}
+
+func TestBlockSlowSubscriber(t *testing.T) {
+	h := New()
+	s := h.Subscribe(1, Block)
+	const n = 50
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := uint32(1); i <= n; i++ {
+			h.Publish(packet(i))
+		}
+	}()
+
+	for i := uint32(1); i <= n; i++ {
+		time.Sleep(100 * time.Microsecond)
+		p := <-s.C
+		if p.TimestampMS != i {
+			t.Fatalf("got packet %d, want %d", p.TimestampMS, i)
+		}
+	}
+	<-done
+	if s.Dropped() != 0 {
+		t.Fatalf("dropped %d, want 0", s.Dropped())
+	}
+}
+
+func TestLatest(t *testing.T) {
+	h := New()
+	if _, ok := h.Latest(); ok {
+		t.Fatal("Latest reported a packet before any was published")
+	}
+	h.Publish(packet(7))
+	p, ok := h.Latest()
+	if !ok || p.TimestampMS != 7 {
+		t.Fatalf("got %d %v, want 7 true", p.TimestampMS, ok)
+	}
+}
+
+func TestConcurrentPublishSubscribeClose(t *testing.T) {
+	h := New()
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ts := uint32(0); ; ts++ {
+				select {
+				case <-stop:
+					return
+				default:
+					h.Publish(packet(ts))
+				}
+			}
+		}()
+	}
+	for _, policy := range []DropPolicy{DropNewest, DropOldest, Block} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(policy DropPolicy) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					s := h.Subscribe(4, policy)
+					for k := 0; k < 3; k++ {
+						if _, ok := <-s.C; !ok {
+							break
+						}
+					}
+					s.Close()
+					s.Close()
+				}
+			}(policy)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	// Close while publishers and subscribers are still running, which also
+	// releases subscribers waiting for packets.
+	h.Close()
+	close(stop)
+	wg.Wait()
+	if n := h.Subscribers(); n != 0 {
+		t.Fatalf("%d subscribers left after Close", n)
+	}
+}
+
+func TestCloseWhilePublishing(t *testing.T) {
+	h := New()
+	// Never read, so a Block publish stays stuck until Close.
+	blocked := h.Subscribe(1, Block)
+	others := []*Subscription{h.Subscribe(1, DropNewest), h.Subscribe(1, DropOldest)}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ts := uint32(0); ts < 1000; ts++ {
+			h.Publish(packet(ts))
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	h.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish still blocked after Close")
+	}
+
+	for _, s := range append(others, blocked) {
+		drain(s)
+		if _, ok := <-s.C; ok {
+			t.Fatal("subscription channel not closed by Close")
+		}
+	}
+	if s := h.Subscribe(1, DropNewest); s != nil {
+		if _, ok := <-s.C; ok {
+			t.Fatal("subscription after Close is open")
+		}
+	}
+}
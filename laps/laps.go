@@ -0,0 +1,199 @@
+package laps
+
+import (
+	"math"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/gforce"
+)
+
+// Minimum lateral acceleration in m/s² for a sample to count as cornering.
+const cornerAccel = 2.0
+
+// A lap that starts with more than this on the lap clock was joined in progress.
+const lapStartTolerance = 1.0
+
+// Longer gaps between packets (pauses, menus) are not counted towards averages.
+const maxSampleGap = 1.0
+
+// Lap is the summary of a completed lap.
+type Lap struct {
+	Number uint16
+	Time   time.Duration
+	// False for laps that were joined in progress or interrupted by a rewind or restart.
+	Valid bool
+	// Top speed in meters per second.
+	TopSpeed float32
+	// Lowest speed while cornering in meters per second.
+	MinCornerSpeed float32
+	FuelUsed       float32
+	TireWear       fmtel.TireWear
+	// Average tire temperatures in fahrenheit.
+	AvgTireTemps fmtel.TireTemperatures
+	G            GStats
+}
+
+// GStats summarises the g-forces of a lap. All values are positive.
+type GStats struct {
+	MaxLateral      float32
+	MaxBraking      float32
+	MaxAcceleration float32
+	// Time weighted averages of the absolute g.
+	AvgLateral      float32
+	AvgLongitudinal float32
+	AvgCombined     float32
+}
+
+// Tracker segments a packet stream into laps.
+type Tracker struct {
+	laps    []Lap
+	current *lap
+	last    fmtel.ForzaPacket
+	hasLast bool
+}
+
+type lap struct {
+	Lap
+	startFuel float32
+	startWear fmtel.TireWear
+	tempSum   [4]float64
+	gSum      [3]float64
+	weight    float64
+	lastTime  float32
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+func newLap(p *fmtel.ForzaPacket) *lap {
+	return &lap{
+		Lap: Lap{
+			Number: p.LapNumber,
+			Valid:  p.CurrentLap <= lapStartTolerance,
+		},
+		startFuel: p.Fuel,
+		startWear: *p.TireWear(),
+	}
+}
+
+func (l *lap) add(p *fmtel.ForzaPacket, dt float64) {
+	if p.Speed > l.TopSpeed {
+		l.TopSpeed = p.Speed
+	}
+	if (p.AccelerationX > cornerAccel || p.AccelerationX < -cornerAccel) &&
+		(l.MinCornerSpeed == 0 || p.Speed < l.MinCornerSpeed) {
+		l.MinCornerSpeed = p.Speed
+	}
+	l.tempSum[0] += float64(p.TireTempFrontLeft) * dt
+	l.tempSum[1] += float64(p.TireTempFrontRight) * dt
+	l.tempSum[2] += float64(p.TireTempRearLeft) * dt
+	l.tempSum[3] += float64(p.TireTempRearRight) * dt
+
+	g := p.GForce()
+	l.G.MaxLateral = max(l.G.MaxLateral, g.Lateral, -g.Lateral)
+	l.G.MaxBraking = max(l.G.MaxBraking, -g.Longitudinal)
+	l.G.MaxAcceleration = max(l.G.MaxAcceleration, g.Longitudinal)
+	l.gSum[0] += math.Abs(float64(g.Lateral)) * dt
+	l.gSum[1] += math.Abs(float64(g.Longitudinal)) * dt
+	l.gSum[2] += float64(gforce.Combined(*g)) * dt
+	l.weight += dt
+	l.lastTime = p.CurrentLap
+}
+
+func (l *lap) finish(end *fmtel.ForzaPacket, lapTime float32) Lap {
+	l.Time = time.Duration(float64(lapTime) * float64(time.Second))
+	l.FuelUsed = l.startFuel - end.Fuel
+	wear := end.TireWear()
+	l.TireWear = fmtel.TireWear{
+		FrontLeft:  wear.FrontLeft - l.startWear.FrontLeft,
+		FrontRight: wear.FrontRight - l.startWear.FrontRight,
+		RearLeft:   wear.RearLeft - l.startWear.RearLeft,
+		RearRight:  wear.RearRight - l.startWear.RearRight,
+	}
+	if l.weight > 0 {
+		l.AvgTireTemps = fmtel.TireTemperatures{
+			FrontLeft:  float32(l.tempSum[0] / l.weight),
+			FrontRight: float32(l.tempSum[1] / l.weight),
+			RearLeft:   float32(l.tempSum[2] / l.weight),
+			RearRight:  float32(l.tempSum[3] / l.weight),
+		}
+		l.G.AvgLateral = float32(l.gSum[0] / l.weight)
+		l.G.AvgLongitudinal = float32(l.gSum[1] / l.weight)
+		l.G.AvgCombined = float32(l.gSum[2] / l.weight)
+	}
+	return l.Lap
+}
+
+// Returns true if the packet belongs to a new session or a rewind of the current lap.
+func restarted(prev, p *fmtel.ForzaPacket) bool {
+	return p.LapNumber < prev.LapNumber ||
+		p.CurrentRaceTime < prev.CurrentRaceTime ||
+		(p.LapNumber == prev.LapNumber && p.CurrentLap < prev.CurrentLap)
+}
+
+// Feeds a packet to the tracker. Returns the lap completed by this packet, if any.
+// Only packets sent while a race is on should be passed in.
+func (t *Tracker) Update(p *fmtel.ForzaPacket) (Lap, bool) {
+	if !t.hasLast {
+		t.current = newLap(p)
+		t.last = *p
+		t.hasLast = true
+		return Lap{}, false
+	}
+	prev := &t.last
+
+	// Unsigned subtraction keeps the interval correct when TimestampMS wraps around.
+	dt := float64(p.TimestampMS-prev.TimestampMS) / 1000
+	if dt > maxSampleGap {
+		dt = 0
+	}
+
+	var done Lap
+	var completed bool
+	switch {
+	case p.LapNumber > prev.LapNumber:
+		lapTime := p.LastLap
+		if lapTime <= 0 {
+			lapTime = t.current.lastTime
+		}
+		done = t.current.finish(prev, lapTime)
+		completed = true
+		t.laps = append(t.laps, done)
+		t.current = newLap(p)
+	case restarted(prev, p):
+		t.current = newLap(p)
+		t.current.Valid = t.current.Valid && p.LapNumber != prev.LapNumber
+	default:
+		t.current.add(p, dt)
+	}
+
+	t.last = *p
+	return done, completed
+}
+
+// Returns all completed laps, oldest first.
+func (t *Tracker) Laps() []Lap {
+	return t.laps
+}
+
+// Returns the fastest valid lap.
+func (t *Tracker) Best() (Lap, bool) {
+	var best Lap
+	found := false
+	for _, l := range t.laps {
+		if l.Valid && (!found || l.Time < best.Time) {
+			best = l
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Forgets all laps and the lap in progress.
+func (t *Tracker) Reset() {
+	t.laps = nil
+	t.current = nil
+	t.hasLast = false
+}
@@ -0,0 +1,178 @@
+package laps
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Returns a packet at timestamp ts (ms) on lap with the given lap and race clocks.
+func pk(ts uint32, lap uint16, lapTime, raceTime float32) fmtel.ForzaPacket {
+	return fmtel.ForzaPacket{
+		TimestampMS:       ts,
+		LapNumber:         lap,
+		CurrentLap:        lapTime,
+		CurrentRaceTime:   raceTime,
+		Fuel:              1 - raceTime/100,
+		TireTempFrontLeft: 180,
+	}
+}
+
+// Returns packets every 100ms from lapTime to end on a lap, starting at timestamp ts.
+func drive(ts uint32, lap uint16, lapTime, raceTime, end float32) []fmtel.ForzaPacket {
+	var ps []fmtel.ForzaPacket
+	for ; lapTime <= end+1e-3; lapTime, raceTime, ts = lapTime+0.1, raceTime+0.1, ts+100 {
+		ps = append(ps, pk(ts, lap, lapTime, raceTime))
+	}
+	return ps
+}
+
+// Returns the packet crossing the line onto lap after a lap of lastLap seconds.
+func cross(ts uint32, lap uint16, lastLap, raceTime float32) fmtel.ForzaPacket {
+	p := pk(ts, lap, 0, raceTime)
+	p.LastLap = lastLap
+	return p
+}
+
+func concat(parts ...[]fmtel.ForzaPacket) []fmtel.ForzaPacket {
+	var ps []fmtel.ForzaPacket
+	for _, part := range parts {
+		ps = append(ps, part...)
+	}
+	return ps
+}
+
+func TestUpdate(t *testing.T) {
+	type want struct {
+		number uint16
+		time   time.Duration
+		valid  bool
+	}
+	tests := []struct {
+		name    string
+		packets []fmtel.ForzaPacket
+		laps    []want
+		best    bool
+	}{
+		{
+			name: "normal lap",
+			packets: concat(
+				drive(1000, 0, 0, 0, 10),
+				[]fmtel.ForzaPacket{cross(11100, 1, 10.5, 10.6)},
+			),
+			laps: []want{{0, 10500 * time.Millisecond, true}},
+			best: true,
+		},
+		{
+			name: "timestamp wrap",
+			packets: concat(
+				drive(math.MaxUint32-2000, 0, 0, 0, 10),
+				[]fmtel.ForzaPacket{cross(8000, 1, 10.5, 10.6)},
+			),
+			laps: []want{{0, 10500 * time.Millisecond, true}},
+			best: true,
+		},
+		{
+			name: "joined in progress",
+			packets: concat(
+				drive(1000, 3, 30, 200, 35),
+				[]fmtel.ForzaPacket{cross(6100, 4, 35.5, 205.6)},
+			),
+			laps: []want{{3, 35500 * time.Millisecond, false}},
+		},
+		{
+			name: "restart mid-lap",
+			packets: concat(
+				drive(1000, 2, 0, 100, 5),
+				// The session restarts: lap and race clocks start over.
+				drive(7000, 0, 0, 0, 10),
+				[]fmtel.ForzaPacket{cross(17100, 1, 10.5, 10.6)},
+			),
+			laps: []want{{0, 10500 * time.Millisecond, true}},
+			best: true,
+		},
+		{
+			name: "rewind invalidates the lap",
+			packets: concat(
+				drive(1000, 0, 0, 0, 6),
+				// Rewound by two seconds.
+				drive(7100, 0, 4, 4, 10),
+				[]fmtel.ForzaPacket{cross(13200, 1, 10.5, 10.6)},
+			),
+			laps: []want{{0, 10500 * time.Millisecond, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracker()
+			for i := range tt.packets {
+				tr.Update(&tt.packets[i])
+			}
+			laps := tr.Laps()
+			if len(laps) != len(tt.laps) {
+				t.Fatalf("got %d laps, want %d", len(laps), len(tt.laps))
+			}
+			for i, w := range tt.laps {
+				l := laps[i]
+				if l.Number != w.number || l.Time != w.time || l.Valid != w.valid {
+					t.Errorf("lap %d: got number %d, time %v, valid %v, want %d, %v, %v",
+						i, l.Number, l.Time, l.Valid, w.number, w.time, w.valid)
+				}
+				// Every sample is weighted by the time since the previous one,
+				// so a constant temperature averages to itself.
+				if l.AvgTireTemps.FrontLeft != 180 {
+					t.Errorf("lap %d: average front left temperature %v, want 180", i, l.AvgTireTemps.FrontLeft)
+				}
+			}
+			if _, ok := tr.Best(); ok != tt.best {
+				t.Errorf("best lap found %v, want %v", ok, tt.best)
+			}
+		})
+	}
+}
+
+func TestFuelUsed(t *testing.T) {
+	tr := NewTracker()
+	ps := concat(drive(1000, 0, 0, 0, 10), []fmtel.ForzaPacket{cross(11100, 1, 10.5, 10.6)})
+	var done Lap
+	var ok bool
+	for i := range ps {
+		if l, completed := tr.Update(&ps[i]); completed {
+			done, ok = l, true
+		}
+	}
+	if !ok {
+		t.Fatal("lap not completed")
+	}
+	// Fuel drops by 1% per second of race time.
+	if math.Abs(float64(done.FuelUsed)-0.1) > 1e-3 {
+		t.Fatalf("fuel used %v, want 0.1", done.FuelUsed)
+	}
+}
+
+func TestTimestampWrapWeighting(t *testing.T) {
+	ps := []fmtel.ForzaPacket{
+		pk(math.MaxUint32-49, 0, 0, 0),
+		// 100ms later, after TimestampMS wrapped around.
+		pk(50, 0, 0.1, 0.1),
+		pk(150, 0, 0.2, 0.2),
+		cross(250, 1, 0.3, 0.3),
+	}
+	ps[1].TireTempFrontLeft = 200
+	ps[2].TireTempFrontLeft = 100
+
+	tr := NewTracker()
+	for i := range ps {
+		tr.Update(&ps[i])
+	}
+	laps := tr.Laps()
+	if len(laps) != 1 {
+		t.Fatalf("got %d laps, want 1", len(laps))
+	}
+	// Both samples count for 100ms, including the one across the wrap.
+	if got := laps[0].AvgTireTemps.FrontLeft; got != 150 {
+		t.Fatalf("average front left temperature %v, want 150", got)
+	}
+}
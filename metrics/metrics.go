@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/hub"
+)
+
+// Metrics exposes server counters and the latest packet in the Prometheus text format.
+type Metrics struct {
+	PacketsReceived  atomic.Uint64
+	DecodeErrors     atomic.Uint64
+	ReadErrors       atomic.Uint64
+	DuplicateDropped atomic.Uint64
+	JsonRequests     atomic.Uint64
+
+	hub     *hub.Hub
+	mu      sync.Mutex
+	clients map[string]func() int
+}
+
+func New(h *hub.Hub) *Metrics {
+	return &Metrics{
+		hub:     h,
+		clients: make(map[string]func() int),
+	}
+}
+
+// Registers a function reporting the number of clients connected to an endpoint.
+func (m *Metrics) AddClientGauge(endpoint string, count func() int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients[endpoint] = count
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Write(w)
+}
+
+type writer struct {
+	*bufio.Writer
+}
+
+func (w *writer) header(name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (w *writer) counter(name, help string, v uint64) {
+	w.header(name, "counter", help)
+	fmt.Fprintf(w, "%s %d\n", name, v)
+}
+
+// Writes every metric in the Prometheus text exposition format.
+func (m *Metrics) Write(out io.Writer) error {
+	w := &writer{Writer: bufio.NewWriter(out)}
+
+	w.counter("fmtel_packets_received_total", "Telemetry packets received.", m.PacketsReceived.Load())
+	w.counter("fmtel_decode_errors_total", "Datagrams rejected because they could not be decoded.", m.DecodeErrors.Load())
+	w.counter("fmtel_read_errors_total", "Errors reading from the UDP connection.", m.ReadErrors.Load())
+	w.counter("fmtel_duplicate_packets_dropped_total", "Packets dropped for repeating the previous timestamp.", m.DuplicateDropped.Load())
+	w.counter("fmtel_json_requests_total", "Requests served by the JSON endpoint.", m.JsonRequests.Load())
+
+	m.mu.Lock()
+	endpoints := make([]string, 0, len(m.clients))
+	for endpoint := range m.clients {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	w.header("fmtel_connected_clients", "gauge", "Clients connected to a streaming endpoint.")
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "fmtel_connected_clients{endpoint=%q} %d\n", endpoint, m.clients[endpoint]())
+	}
+	m.mu.Unlock()
+
+	if p, ok := m.hub.Latest(); ok {
+		writePacket(w, &p)
+	}
+
+	return w.Flush()
+}
+
+// Writes the gauges of the fields the source sent. Fields it does not send are
+// left out rather than reported as zero.
+func writePacket(w *writer, p *decoder.Packet) {
+	labels := fmt.Sprintf("car_ordinal=\"%d\"", p.CarOrdinal)
+	if p.HasField("TrackOrdinal") {
+		labels += fmt.Sprintf(",track_ordinal=\"%d\"", p.TrackOrdinal)
+	}
+	gauge := func(name, help, field string, v float64) {
+		if !p.HasField(field) {
+			return
+		}
+		w.header(name, "gauge", help)
+		fmt.Fprintf(w, "%s{%s} %g\n", name, labels, v)
+	}
+	tires := func(name, help, field string, fl, fr, rl, rr float32) {
+		if !p.HasField(field) {
+			return
+		}
+		w.header(name, "gauge", help)
+		for _, t := range []struct {
+			tire  string
+			value float32
+		}{{"front_left", fl}, {"front_right", fr}, {"rear_left", rl}, {"rear_right", rr}} {
+			fmt.Fprintf(w, "%s{%s,tire=%q} %g\n", name, labels, t.tire, t.value)
+		}
+	}
+
+	gauge("fmtel_race_on", "1 while a race is on.", "IsRaceOn", float64(p.IsRaceOn))
+	gauge("fmtel_engine_rpm", "Current engine speed.", "CurrentEngineRpm", float64(p.CurrentEngineRpm))
+	gauge("fmtel_engine_max_rpm", "Engine redline.", "EngineMaxRpm", float64(p.EngineMaxRpm))
+	gauge("fmtel_speed_meters_per_second", "Current speed.", "Speed", float64(p.Speed))
+	gauge("fmtel_gear", "Current gear.", "Gear", float64(p.Gear))
+	gauge("fmtel_power_watts", "Current engine power.", "Power", float64(p.Power))
+	gauge("fmtel_torque_newton_meters", "Current engine torque.", "Torque", float64(p.Torque))
+	gauge("fmtel_boost_psi", "Current boost pressure.", "Boost", float64(p.Boost))
+	gauge("fmtel_fuel_ratio", "Fuel left, from 0 to 1.", "Fuel", float64(p.Fuel))
+	gauge("fmtel_lap_number", "Current lap.", "LapNumber", float64(p.LapNumber))
+	gauge("fmtel_race_position", "Current race position.", "RacePosition", float64(p.RacePosition))
+	tires("fmtel_tire_temp_fahrenheit", "Tire temperature.", "TireTempFrontLeft",
+		p.TireTempFrontLeft, p.TireTempFrontRight, p.TireTempRearLeft, p.TireTempRearRight)
+	tires("fmtel_tire_wear_ratio", "Tire wear, from 0 to 1.", "TireWearFrontLeft",
+		p.TireWearFrontLeft, p.TireWearFrontRight, p.TireWearRearLeft, p.TireWearRearRight)
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/hub"
+)
+
+var (
+	commentLine = regexp.MustCompile(`^# (HELP|TYPE) ([a-z_]+) (.+)$`)
+	sampleLine  = regexp.MustCompile(`^([a-z_]+)(\{[a-z_]+="[^"]*"(,[a-z_]+="[^"]*")*\})? (\S+)$`)
+)
+
+// Checks the body is valid Prometheus text and returns the samples by name and labels.
+func parse(t *testing.T, body string) map[string]float64 {
+	t.Helper()
+	samples := make(map[string]float64)
+	typed := ""
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if m := commentLine.FindStringSubmatch(line); m != nil {
+			if m[1] == "TYPE" {
+				if m[3] != "counter" && m[3] != "gauge" {
+					t.Errorf("%s has type %q", m[2], m[3])
+				}
+				typed = m[2]
+			}
+			continue
+		}
+		m := sampleLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("invalid line %q", line)
+			continue
+		}
+		if m[1] != typed {
+			t.Errorf("sample %s follows the TYPE of %s", m[1], typed)
+		}
+		v, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			t.Errorf("invalid value in %q", line)
+		}
+		samples[m[1]+m[2]] = v
+	}
+	return samples
+}
+
+func scrape(t *testing.T, m *Metrics) map[string]float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+	return parse(t, rec.Body.String())
+}
+
+func TestCounters(t *testing.T) {
+	h := hub.New()
+	defer h.Close()
+	m := New(h)
+	m.PacketsReceived.Add(3)
+	m.DecodeErrors.Add(1)
+	m.AddClientGauge("ws", func() int { return 2 })
+
+	samples := scrape(t, m)
+	want := map[string]float64{
+		"fmtel_packets_received_total":           3,
+		"fmtel_decode_errors_total":              1,
+		"fmtel_read_errors_total":                0,
+		"fmtel_duplicate_packets_dropped_total":  0,
+		"fmtel_json_requests_total":              0,
+		`fmtel_connected_clients{endpoint="ws"}`: 2,
+	}
+	for name, v := range want {
+		if got, ok := samples[name]; !ok || got != v {
+			t.Errorf("%s = %v, want %v", name, got, v)
+		}
+	}
+	// Without a packet only the server metrics are reported.
+	if len(samples) != len(want) {
+		t.Errorf("got %d samples, want %d", len(samples), len(want))
+	}
+}
+
+func TestPacketGauges(t *testing.T) {
+	h := hub.New()
+	defer h.Close()
+	m := New(h)
+
+	p := decoder.Packet{Format: decoder.Dash, Fields: decoder.Dash.Fields()}
+	p.CarOrdinal = 12
+	p.IsRaceOn = 1
+	p.Speed = 40.5
+	p.Gear = 3
+	p.TireTempFrontLeft = 180
+	h.Publish(p)
+
+	samples := scrape(t, m)
+	labels := `{car_ordinal="12"}`
+	want := map[string]float64{
+		"fmtel_race_on" + labels:                                         1,
+		"fmtel_speed_meters_per_second" + labels:                         40.5,
+		"fmtel_gear" + labels:                                            3,
+		`fmtel_tire_temp_fahrenheit{car_ordinal="12",tire="front_left"}`: 180,
+	}
+	for name, v := range want {
+		if got, ok := samples[name]; !ok || got != v {
+			t.Errorf("%s = %v, want %v", name, got, v)
+		}
+	}
+	// The dash layout carries neither tire wear nor the track.
+	for name := range samples {
+		if strings.HasPrefix(name, "fmtel_tire_wear_ratio") || strings.Contains(name, "track_ordinal") {
+			t.Errorf("got %s for a dash packet", name)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package motec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Rate at which the game sends packets, used as the frequency of every channel.
+const Frequency = 60
+
+const gravity = 9.80665
+
+// File layout, all little-endian:
+//
+//	header    1762 bytes at 0
+//	event     1154 bytes
+//	venue     1100 bytes
+//	vehicle    260 bytes
+//	channels   124 bytes each, a doubly linked list
+//	data      float32 samples, one block per channel
+const (
+	headerSize  = 1762
+	eventSize   = 1154
+	venueSize   = 1100
+	vehicleSize = 260
+	channelSize = 124
+
+	eventPtr   = headerSize
+	venuePtr   = eventPtr + eventSize
+	vehiclePtr = venuePtr + venueSize
+	channelPtr = vehiclePtr + vehicleSize
+)
+
+// Session metadata stored in the file header.
+type Session struct {
+	Driver  string
+	Vehicle string
+	// Vehicle class or group.
+	VehicleType string
+	// Vehicle weight in kilograms.
+	Weight  uint32
+	Venue   string
+	Event   string
+	Session string
+	Comment string
+	Date    time.Time
+}
+
+// Sample is a packet together with the values worked out from the packets before it.
+type Sample struct {
+	*fmtel.ForzaPacket
+	// Distance in meters since the start of the current lap.
+	LapDistance float32
+}
+
+// Channel maps a packet value to a MoTeC channel.
+type Channel struct {
+	Name  string
+	Short string
+	Unit  string
+	Value func(p Sample) float32
+}
+
+func percent(v uint8) float32 {
+	return float32(v) / 255 * 100
+}
+
+func celsius(f float32) float32 {
+	return (f - 32) * 5 / 9
+}
+
+// Channels written for every packet.
+var Channels = []Channel{
+	{"Ground Speed", "Speed", "km/h", func(p Sample) float32 { return p.Speed * 3.6 }},
+	{"Engine RPM", "RPM", "rpm", func(p Sample) float32 { return p.CurrentEngineRpm }},
+	{"Gear", "Gear", "", func(p Sample) float32 { return float32(p.Gear) }},
+	{"Throttle Pos", "Throttle", "%", func(p Sample) float32 { return percent(p.Accel) }},
+	{"Brake Pos", "Brake", "%", func(p Sample) float32 { return percent(p.Brake) }},
+	{"Clutch Pos", "Clutch", "%", func(p Sample) float32 { return percent(p.Clutch) }},
+	{"Handbrake Pos", "HBrake", "%", func(p Sample) float32 { return percent(p.HandBrake) }},
+	{"Steering Angle", "Steer", "%", func(p Sample) float32 { return float32(p.Steer) / 127 * 100 }},
+	{"Engine Power", "Power", "kW", func(p Sample) float32 { return p.Power / 1000 }},
+	{"Engine Torque", "Torque", "Nm", func(p Sample) float32 { return p.Torque }},
+	{"Boost Pressure", "Boost", "psi", func(p Sample) float32 { return p.Boost }},
+	{"Fuel Level", "Fuel", "%", func(p Sample) float32 { return p.Fuel * 100 }},
+	{"G Force Lat", "GLat", "G", func(p Sample) float32 { return p.AccelerationX / gravity }},
+	{"G Force Long", "GLong", "G", func(p Sample) float32 { return p.AccelerationZ / gravity }},
+	{"G Force Vert", "GVert", "G", func(p Sample) float32 { return p.AccelerationY / gravity }},
+	{"Lap Number", "Lap", "", func(p Sample) float32 { return float32(p.LapNumber) }},
+	{"Lap Time", "LapTime", "s", func(p Sample) float32 { return p.CurrentLap }},
+	{"Lap Distance", "Dist", "m", func(p Sample) float32 { return p.LapDistance }},
+	{"Race Position", "Pos", "", func(p Sample) float32 { return float32(p.RacePosition) }},
+	{"Car Pos X", "PosX", "m", func(p Sample) float32 { return p.PositionX }},
+	{"Car Pos Y", "PosY", "m", func(p Sample) float32 { return p.PositionY }},
+	{"Car Pos Z", "PosZ", "m", func(p Sample) float32 { return p.PositionZ }},
+	{"Tyre Temp FL", "TTempFL", "C", func(p Sample) float32 { return celsius(p.TireTempFrontLeft) }},
+	{"Tyre Temp FR", "TTempFR", "C", func(p Sample) float32 { return celsius(p.TireTempFrontRight) }},
+	{"Tyre Temp RL", "TTempRL", "C", func(p Sample) float32 { return celsius(p.TireTempRearLeft) }},
+	{"Tyre Temp RR", "TTempRR", "C", func(p Sample) float32 { return celsius(p.TireTempRearRight) }},
+	{"Tyre Wear FL", "TWearFL", "%", func(p Sample) float32 { return p.TireWearFrontLeft * 100 }},
+	{"Tyre Wear FR", "TWearFR", "%", func(p Sample) float32 { return p.TireWearFrontRight * 100 }},
+	{"Tyre Wear RL", "TWearRL", "%", func(p Sample) float32 { return p.TireWearRearLeft * 100 }},
+	{"Tyre Wear RR", "TWearRR", "%", func(p Sample) float32 { return p.TireWearRearRight * 100 }},
+	{"Tyre Slip Ratio FL", "SlipFL", "", func(p Sample) float32 { return p.TireSlipRatioFrontLeft }},
+	{"Tyre Slip Ratio FR", "SlipFR", "", func(p Sample) float32 { return p.TireSlipRatioFrontRight }},
+	{"Tyre Slip Ratio RL", "SlipRL", "", func(p Sample) float32 { return p.TireSlipRatioRearLeft }},
+	{"Tyre Slip Ratio RR", "SlipRR", "", func(p Sample) float32 { return p.TireSlipRatioRearRight }},
+	{"Wheel Speed FL", "WSpdFL", "rad/s", func(p Sample) float32 { return p.WheelRotationSpeedFrontLeft }},
+	{"Wheel Speed FR", "WSpdFR", "rad/s", func(p Sample) float32 { return p.WheelRotationSpeedFrontRight }},
+	{"Wheel Speed RL", "WSpdRL", "rad/s", func(p Sample) float32 { return p.WheelRotationSpeedRearLeft }},
+	{"Wheel Speed RR", "WSpdRR", "rad/s", func(p Sample) float32 { return p.WheelRotationSpeedRearRight }},
+	{"Susp Pos FL", "SuspFL", "mm", func(p Sample) float32 { return p.SuspensionTravelMetersFrontLeft * 1000 }},
+	{"Susp Pos FR", "SuspFR", "mm", func(p Sample) float32 { return p.SuspensionTravelMetersFrontRight * 1000 }},
+	{"Susp Pos RL", "SuspRL", "mm", func(p Sample) float32 { return p.SuspensionTravelMetersRearLeft * 1000 }},
+	{"Susp Pos RR", "SuspRR", "mm", func(p Sample) float32 { return p.SuspensionTravelMetersRearRight * 1000 }},
+}
+
+// Writer buffers packets and writes them as a MoTeC i2 log. The whole file is
+// written at once because the header points at every channel's data.
+type Writer struct {
+	Session Session
+	samples [][]float32
+	first   fmtel.ForzaPacket
+	// Lap number and DistanceTraveled at the start of the current lap.
+	lap      uint16
+	lapStart float32
+}
+
+func NewWriter(s Session) *Writer {
+	return &Writer{
+		Session: s,
+		samples: make([][]float32, len(Channels)),
+	}
+}
+
+// Adds a packet as one sample on every channel.
+func (w *Writer) Add(p *fmtel.ForzaPacket) {
+	if len(w.samples[0]) == 0 {
+		w.first = *p
+		w.lap, w.lapStart = p.LapNumber, p.DistanceTraveled
+	}
+	// A new lap, or a restart that put the car behind the lap start.
+	if p.LapNumber != w.lap || p.DistanceTraveled < w.lapStart {
+		w.lap, w.lapStart = p.LapNumber, p.DistanceTraveled
+	}
+	s := Sample{ForzaPacket: p, LapDistance: p.DistanceTraveled - w.lapStart}
+	for i, c := range Channels {
+		w.samples[i] = append(w.samples[i], c.Value(s))
+	}
+}
+
+// Returns the number of samples per channel.
+func (w *Writer) Len() int {
+	return len(w.samples[0])
+}
+
+// Fills a fixed-size string field, truncating if needed.
+func putString(b []byte, s string) {
+	n := copy(b, s)
+	for i := n; i < len(b); i++ {
+		b[i] = 0
+	}
+}
+
+func (w *Writer) session() Session {
+	s := w.Session
+	if s.Vehicle == "" {
+		s.Vehicle = fmt.Sprintf("Car %d", w.first.CarOrdinal)
+	}
+	if s.Venue == "" {
+		s.Venue = fmt.Sprintf("Track %d", w.first.TrackOrdinal)
+	}
+	if s.Date.IsZero() {
+		s.Date = time.Now()
+	}
+	return s
+}
+
+// Writes the log to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	le := binary.LittleEndian
+	s := w.session()
+	n := w.Len()
+	dataPtr := channelPtr + channelSize*len(Channels)
+
+	var buf bytes.Buffer
+
+	head := make([]byte, headerSize)
+	le.PutUint32(head[0:], 0x40)
+	le.PutUint32(head[8:], channelPtr)
+	le.PutUint32(head[12:], uint32(dataPtr))
+	le.PutUint32(head[36:], eventPtr)
+	le.PutUint16(head[64:], 1)
+	le.PutUint16(head[66:], 0x4240)
+	le.PutUint16(head[68:], 0xf)
+	le.PutUint32(head[70:], 0x1f44)
+	putString(head[74:82], "ADL")
+	le.PutUint16(head[82:], 420)
+	le.PutUint16(head[84:], 0xadb0)
+	le.PutUint32(head[86:], uint32(len(Channels)))
+	putString(head[94:110], s.Date.Format("02/01/2006"))
+	putString(head[126:142], s.Date.Format("15:04:05"))
+	putString(head[158:222], s.Driver)
+	putString(head[222:286], s.Vehicle)
+	putString(head[350:414], s.Venue)
+	le.PutUint32(head[1502:], 0xc81a4)
+	putString(head[1572:1636], s.Comment)
+	buf.Write(head)
+
+	event := make([]byte, eventSize)
+	putString(event[0:64], s.Event)
+	putString(event[64:128], s.Session)
+	putString(event[128:1152], s.Comment)
+	le.PutUint16(event[1152:], venuePtr)
+	buf.Write(event)
+
+	venue := make([]byte, venueSize)
+	putString(venue[0:64], s.Venue)
+	le.PutUint16(venue[1098:], vehiclePtr)
+	buf.Write(venue)
+
+	vehicle := make([]byte, vehicleSize)
+	putString(vehicle[0:64], s.Vehicle)
+	le.PutUint32(vehicle[192:], s.Weight)
+	putString(vehicle[196:228], s.VehicleType)
+	buf.Write(vehicle)
+
+	for i, c := range Channels {
+		meta := make([]byte, channelSize)
+		ptr := channelPtr + channelSize*i
+		if i > 0 {
+			le.PutUint32(meta[0:], uint32(ptr-channelSize))
+		}
+		if i < len(Channels)-1 {
+			le.PutUint32(meta[4:], uint32(ptr+channelSize))
+		}
+		le.PutUint32(meta[8:], uint32(dataPtr+4*n*i))
+		le.PutUint32(meta[12:], uint32(n))
+		le.PutUint16(meta[16:], uint16(0x2ee1+i))
+		// Data type 0x07 with size 4 is a float32.
+		le.PutUint16(meta[18:], 0x07)
+		le.PutUint16(meta[20:], 4)
+		le.PutUint16(meta[22:], Frequency)
+		// Shift 0, multiplier 1, scale 1 and 0 decimal places: readers compute
+		// (value/scale*10^-dec + shift)*mul, so float32 values are read as-is.
+		le.PutUint16(meta[24:], 0)
+		le.PutUint16(meta[26:], 1)
+		le.PutUint16(meta[28:], 1)
+		le.PutUint16(meta[30:], 0)
+		putString(meta[32:64], c.Name)
+		putString(meta[64:72], c.Short)
+		putString(meta[72:84], c.Unit)
+		buf.Write(meta)
+	}
+
+	var value [4]byte
+	for i := range Channels {
+		for _, v := range w.samples[i] {
+			le.PutUint32(value[:], math.Float32bits(v))
+			buf.Write(value[:])
+		}
+	}
+
+	return buf.WriteTo(out)
+}
+
+// Writes the log to a file at path.
+func (w *Writer) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package motec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func padded(s string, n int) []byte {
+	b := make([]byte, n)
+	copy(b, s)
+	return b
+}
+
+func writeLog(t *testing.T, packets ...fmtel.ForzaPacket) []byte {
+	t.Helper()
+	w := NewWriter(Session{
+		Driver:  "Driver",
+		Vehicle: "Vehicle",
+		Venue:   "Venue",
+		Date:    time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC),
+	})
+	for i := range packets {
+		w.Add(&packets[i])
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHeaderLayout(t *testing.T) {
+	if len(Channels) != 42 {
+		t.Fatalf("%d channels, the golden bytes below assume 42", len(Channels))
+	}
+	b := writeLog(t, fmtel.ForzaPacket{Speed: 10}, fmtel.ForzaPacket{Speed: 20})
+
+	// Channel list at 4276, data at 4276 + 42*124 = 9484, event at 1762.
+	want := unhex(t, `
+		40000000 00000000 b4100000 0c250000
+		00000000 00000000 00000000 00000000 00000000
+		e2060000
+		00000000 00000000 00000000 00000000 00000000 00000000
+		0100 4042 0f00 441f0000
+		41444c0000000000
+		a401 b0ad 2a000000 00000000`)
+	want = append(want, padded("09/03/2024", 16)...)
+	if !bytes.Equal(b[:len(want)], want) {
+		t.Fatalf("header\n got %x\nwant %x", b[:len(want)], want)
+	}
+	if got := string(bytes.TrimRight(b[126:142], "\x00")); got != "14:05:06" {
+		t.Fatalf("time %q", got)
+	}
+	if got := string(bytes.TrimRight(b[158:222], "\x00")); got != "Driver" {
+		t.Fatalf("driver %q", got)
+	}
+	if got := string(bytes.TrimRight(b[350:414], "\x00")); got != "Venue" {
+		t.Fatalf("venue %q", got)
+	}
+	if len(b) != 9484+42*2*4 {
+		t.Fatalf("file is %d bytes, want %d", len(b), 9484+42*2*4)
+	}
+}
+
+func TestChannelLayout(t *testing.T) {
+	b := writeLog(t, fmtel.ForzaPacket{Speed: 10}, fmtel.ForzaPacket{Speed: 20})
+
+	want := unhex(t, `
+		00000000 30110000 0c250000 02000000
+		e12e 0700 0400 3c00
+		0000 0100 0100 0000`)
+	want = append(want, padded("Ground Speed", 32)...)
+	want = append(want, padded("Speed", 8)...)
+	want = append(want, padded("km/h", 12)...)
+	want = append(want, make([]byte, 40)...)
+	if got := b[4276 : 4276+124]; !bytes.Equal(got, want) {
+		t.Fatalf("first channel\n got %x\nwant %x", got, want)
+	}
+
+	// The last channel links back to the one before it and ends the list.
+	last := b[4276+41*124:]
+	if prev := binary.LittleEndian.Uint32(last[0:]); prev != 4276+40*124 {
+		t.Fatalf("last channel prev = %d", prev)
+	}
+	if next := binary.LittleEndian.Uint32(last[4:]); next != 0 {
+		t.Fatalf("last channel next = %d", next)
+	}
+
+	for i := range Channels {
+		meta := b[4276+i*124:]
+		if dec := binary.LittleEndian.Uint16(meta[30:]); dec != 0 {
+			t.Fatalf("channel %q has %d decimal places, float32 data would be scaled", Channels[i].Name, dec)
+		}
+	}
+}
+
+func sample(b []byte, channel, i int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(b[9484+4*(2*channel+i):]))
+}
+
+func channelIndex(t *testing.T, name string) int {
+	for i, c := range Channels {
+		if c.Name == name {
+			return i
+		}
+	}
+	t.Fatalf("no channel %q", name)
+	return 0
+}
+
+func TestSamples(t *testing.T) {
+	b := writeLog(t, fmtel.ForzaPacket{Speed: 10}, fmtel.ForzaPacket{Speed: 20})
+	if got := sample(b, 0, 0); got != 36 {
+		t.Fatalf("speed sample 0 = %v, want 36", got)
+	}
+	if got := sample(b, 0, 1); got != 72 {
+		t.Fatalf("speed sample 1 = %v, want 72", got)
+	}
+}
+
+func TestLapDistance(t *testing.T) {
+	w := NewWriter(Session{})
+	for _, p := range []fmtel.ForzaPacket{
+		{LapNumber: 0, DistanceTraveled: 100},
+		{LapNumber: 0, DistanceTraveled: 350},
+		{LapNumber: 1, DistanceTraveled: 4100},
+		{LapNumber: 1, DistanceTraveled: 4150},
+	} {
+		w.Add(&p)
+	}
+	dist := channelIndex(t, "Lap Distance")
+	want := []float32{0, 250, 0, 50}
+	for i, v := range want {
+		if got := w.samples[dist][i]; got != v {
+			t.Fatalf("lap distance %d = %v, want %v", i, got, v)
+		}
+	}
+}
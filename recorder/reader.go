@@ -0,0 +1,151 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// Reader reads the records of a recording in order.
+type Reader struct {
+	Header Header
+	body   *bufio.Reader
+	close  func() error
+	last   time.Time
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	var header [headerSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRecording, err)
+	}
+	if [4]byte(header[:4]) != magic {
+		return nil, ErrNotRecording
+	}
+
+	h := Header{
+		Version:     binary.LittleEndian.Uint16(header[4:]),
+		Format:      decoder.Format(header[6]),
+		Compression: Compression(header[7]),
+		Start:       time.Unix(0, int64(binary.LittleEndian.Uint64(header[8:]))),
+	}
+	if h.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, h.Version)
+	}
+
+	reader := &Reader{
+		Header: h,
+		last:   h.Start,
+		close:  func() error { return nil },
+	}
+	switch h.Compression {
+	case Gzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader.body = bufio.NewReader(gz)
+		reader.close = gz.Close
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader.body = bufio.NewReader(zr)
+		reader.close = func() error {
+			zr.Close()
+			return nil
+		}
+	default:
+		reader.body = bufio.NewReader(r)
+	}
+	return reader, nil
+}
+
+// Opens the recording at path.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	closeBody := r.close
+	r.close = func() error {
+		closeBody()
+		return f.Close()
+	}
+	return r, nil
+}
+
+// Returns the next record, or io.EOF at the end of the recording.
+func (r *Reader) Next() (Record, error) {
+	delta, err := binary.ReadUvarint(r.body)
+	if err != nil {
+		return Record{}, err
+	}
+	n, err := binary.ReadUvarint(r.body)
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	// The length comes from the file, so check it before allocating.
+	if n > fmtel.PacketSize {
+		return Record{}, fmt.Errorf("%w: record of %d bytes, at most %d", ErrCorrupt, n, fmtel.PacketSize)
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r.body, data)
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	r.last = r.last.Add(time.Duration(delta))
+	return Record{Time: r.last, Data: data}, nil
+}
+
+// Decodes a record using the recording's packet format.
+func (r *Reader) Decode(rec Record) (decoder.Packet, error) {
+	return r.Header.Decode(rec.Data)
+}
+
+func (r *Reader) Close() error {
+	return r.close()
+}
+
+// Reads every record of the recording at path.
+func ReadAll(path string) (Header, []Record, error) {
+	r, err := Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer r.Close()
+
+	var records []Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return r.Header, records, nil
+		}
+		if err != nil {
+			return r.Header, records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
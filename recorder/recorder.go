@@ -0,0 +1,232 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// File layout:
+//
+//	magic       [4]byte "FMTL"
+//	version     uint16
+//	format      uint8   packet layout of the datagrams (decoder.Format)
+//	compression uint8   compression of everything after the header
+//	start       int64   wall-clock start time in unix nanoseconds
+//
+// followed by one record per datagram:
+//
+//	delta  uvarint  nanoseconds since the previous record (or start)
+//	length uvarint
+//	data   [length]byte
+//
+// All fixed-size values are little-endian.
+
+var magic = [4]byte{'F', 'M', 'T', 'L'}
+
+// Current file format version.
+const Version uint16 = 1
+
+const headerSize = 16
+
+type Compression uint8
+
+const (
+	None Compression = iota
+	Gzip
+	Zstd
+)
+
+var (
+	ErrNotRecording       = errors.New("not a telemetry recording")
+	ErrUnsupportedVersion = errors.New("unsupported recording version")
+	ErrFormatMismatch     = errors.New("packet does not match recording format")
+	ErrCorrupt            = errors.New("corrupt recording")
+	ErrDatagramTooLarge   = errors.New("datagram larger than any packet format")
+)
+
+func CompressionFromString(s string) Compression {
+	switch s {
+	case "gzip", "gz":
+		return Gzip
+	case "zstd", "zst":
+		return Zstd
+	default:
+		return None
+	}
+}
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+type Header struct {
+	Version     uint16
+	Format      decoder.Format
+	Compression Compression
+	Start       time.Time
+}
+
+// Decodes a datagram using the recording's packet format.
+func (h *Header) Decode(b []byte) (decoder.Packet, error) {
+	return decoder.Decode(b, h.Format)
+}
+
+// Record is a single datagram and the time it was received.
+type Record struct {
+	Time time.Time
+	Data []byte
+}
+
+// Returns the record's offset from the start of the recording.
+func (r *Record) Offset(h *Header) time.Duration {
+	return r.Time.Sub(h.Start)
+}
+
+// Writer writes a recording. The header is written with the first datagram so the
+// start time matches the first packet.
+type Writer struct {
+	w           io.Writer
+	file        *os.File
+	buf         *bufio.Writer
+	body        io.WriteCloser
+	format      decoder.Format
+	compression Compression
+	last        time.Time
+	started     bool
+	count       int
+	scratch     []byte
+}
+
+func NewWriter(w io.Writer, format decoder.Format, compression Compression) *Writer {
+	return &Writer{
+		w:           w,
+		format:      format,
+		compression: compression,
+		scratch:     make([]byte, 0, 2*binary.MaxVarintLen64+fmtel.PacketSize),
+	}
+}
+
+// Creates a recording file at path.
+func Create(path string, format decoder.Format, compression Compression) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := NewWriter(f, format, compression)
+	w.file = f
+	return w, nil
+}
+
+func (w *Writer) start(at time.Time) error {
+	w.started = true
+	w.last = at
+
+	var header [headerSize]byte
+	copy(header[:4], magic[:])
+	binary.LittleEndian.PutUint16(header[4:], Version)
+	header[6] = uint8(w.format)
+	header[7] = uint8(w.compression)
+	binary.LittleEndian.PutUint64(header[8:], uint64(at.UnixNano()))
+
+	w.buf = bufio.NewWriter(w.w)
+	_, err := w.buf.Write(header[:])
+	if err != nil {
+		return err
+	}
+
+	switch w.compression {
+	case Gzip:
+		w.body = gzip.NewWriter(w.buf)
+	case Zstd:
+		w.body, err = zstd.NewWriter(w.buf)
+	default:
+		w.body = nopCloser{w.buf}
+	}
+	return err
+}
+
+// Writes a raw datagram received at the given time. Datagrams longer than
+// fmtel.PacketSize are rejected with ErrDatagramTooLarge.
+func (w *Writer) Write(b []byte, at time.Time) error {
+	if len(b) > fmtel.PacketSize {
+		return fmt.Errorf("%w: %d bytes", ErrDatagramTooLarge, len(b))
+	}
+	if !w.started {
+		if err := w.start(at); err != nil {
+			return err
+		}
+	}
+	delta := at.Sub(w.last)
+	if delta < 0 {
+		delta = 0
+	}
+	w.last = w.last.Add(delta)
+
+	w.scratch = binary.AppendUvarint(w.scratch[:0], uint64(delta))
+	w.scratch = binary.AppendUvarint(w.scratch, uint64(len(b)))
+	w.scratch = append(w.scratch, b...)
+	_, err := w.body.Write(w.scratch)
+	if err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Writes a decoded packet in the Motorsport layout.
+// Returns ErrFormatMismatch unless the recording format is Auto or Motorsport.
+func (w *Writer) WritePacket(p *fmtel.ForzaPacket, at time.Time) error {
+	if w.format != decoder.Auto && w.format != decoder.Motorsport {
+		return fmt.Errorf("%w: %s", ErrFormatMismatch, w.format)
+	}
+	var b [fmtel.PacketSize]byte
+	p.PutBinary(b[:])
+	return w.Write(b[:], at)
+}
+
+// Returns the number of datagrams written.
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// Flushes the recording and closes the underlying file if the Writer created it.
+func (w *Writer) Close() error {
+	if !w.started {
+		if err := w.start(time.Now()); err != nil {
+			return err
+		}
+	}
+	err := w.body.Close()
+	if err == nil {
+		err = w.buf.Flush()
+	}
+	if w.file != nil {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
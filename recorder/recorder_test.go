@@ -0,0 +1,115 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Unix(1700000000, 123456789)
+	datagrams := [][]byte{
+		bytes.Repeat([]byte{1}, decoder.SledSize),
+		bytes.Repeat([]byte{2}, decoder.DashSize),
+		bytes.Repeat([]byte{3}, fmtel.PacketSize),
+		{},
+	}
+	offsets := []time.Duration{0, 16 * time.Millisecond, 33 * time.Millisecond, time.Second}
+
+	for _, c := range []Compression{None, Gzip, Zstd} {
+		t.Run(c.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, decoder.Auto, c)
+			for i, d := range datagrams {
+				if err := w.Write(d, start.Add(offsets[i])); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if w.Count() != len(datagrams) {
+				t.Fatalf("count %d, want %d", w.Count(), len(datagrams))
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if r.Header.Compression != c || r.Header.Format != decoder.Auto || !r.Header.Start.Equal(start) {
+				t.Fatalf("header %+v", r.Header)
+			}
+			for i, d := range datagrams {
+				rec, err := r.Next()
+				if err != nil {
+					t.Fatalf("record %d: %v", i, err)
+				}
+				if !bytes.Equal(rec.Data, d) {
+					t.Fatalf("record %d: data differs", i)
+				}
+				if got := rec.Offset(&r.Header); got != offsets[i] {
+					t.Fatalf("record %d: offset %v, want %v", i, got, offsets[i])
+				}
+			}
+			if _, err := r.Next(); err != io.EOF {
+				t.Fatalf("got %v after the last record, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestWriteTooLarge(t *testing.T) {
+	w := NewWriter(io.Discard, decoder.Auto, None)
+	err := w.Write(make([]byte, fmtel.PacketSize+1), time.Now())
+	if !errors.Is(err, ErrDatagramTooLarge) {
+		t.Fatalf("got %v, want ErrDatagramTooLarge", err)
+	}
+}
+
+func TestReadCorruptLength(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, decoder.Auto, None)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// A record claiming to be 4 GB long.
+	buf.Write(binary.AppendUvarint(nil, 0))
+	buf.Write(binary.AppendUvarint(nil, 1<<32))
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("got %v, want ErrCorrupt", err)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, decoder.Auto, None)
+	w.Write(make([]byte, fmtel.PacketSize), time.Now())
+	w.Close()
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-10]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestNotRecording(t *testing.T) {
+	_, err := NewReader(bytes.NewReader(make([]byte, 32)))
+	if !errors.Is(err, ErrNotRecording) {
+		t.Fatalf("got %v, want ErrNotRecording", err)
+	}
+}
@@ -0,0 +1,277 @@
+package replay
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/recorder"
+)
+
+// Player plays recorded datagrams back with their original timing.
+// All methods are safe to call while Play is running.
+type Player struct {
+	header  recorder.Header
+	records []recorder.Record
+	offsets []time.Duration
+
+	mu      sync.Mutex
+	pos     int
+	speed   float64
+	loop    bool
+	paused  bool
+	pauseAt time.Duration
+	// Wall-clock time at which playback was at anchorAt.
+	anchor   time.Time
+	anchorAt time.Duration
+	// Incremented on every pause, seek or speed change.
+	gen     uint64
+	changed chan struct{}
+}
+
+func NewPlayer(header recorder.Header, records []recorder.Record) *Player {
+	offsets := make([]time.Duration, len(records))
+	for i := range records {
+		offsets[i] = records[i].Offset(&header)
+	}
+	return &Player{
+		header:  header,
+		records: records,
+		offsets: offsets,
+		speed:   1,
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Loads the recording at path into a new Player.
+func Load(path string) (*Player, error) {
+	h, records, err := recorder.ReadAll(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlayer(h, records), nil
+}
+
+func (p *Player) Header() recorder.Header {
+	return p.header
+}
+
+// Returns the offset of the last record.
+func (p *Player) Duration() time.Duration {
+	if len(p.offsets) == 0 {
+		return 0
+	}
+	return p.offsets[len(p.offsets)-1]
+}
+
+// Returns the current playback offset.
+func (p *Player) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position()
+}
+
+func (p *Player) position() time.Duration {
+	if p.paused {
+		return p.pauseAt
+	}
+	if p.anchor.IsZero() {
+		return p.anchorAt
+	}
+	return p.anchorAt + time.Duration(float64(time.Since(p.anchor))*p.speed)
+}
+
+// Must be called with p.mu held.
+func (p *Player) notify(at time.Duration) {
+	p.anchor = time.Now()
+	p.anchorAt = at
+	p.gen++
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Sets the playback speed multiplier. Values <= 0 are ignored.
+func (p *Player) SetSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	at := p.position()
+	p.speed = speed
+	p.notify(at)
+}
+
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+// Restarts playback from the beginning once the end is reached.
+func (p *Player) SetLoop(loop bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loop = loop
+}
+
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return
+	}
+	p.pauseAt = p.position()
+	p.paused = true
+	p.notify(p.pauseAt)
+}
+
+func (p *Player) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
+		return
+	}
+	p.paused = false
+	p.notify(p.pauseAt)
+}
+
+func (p *Player) TogglePause() {
+	p.mu.Lock()
+	paused := p.paused
+	p.mu.Unlock()
+	if paused {
+		p.Resume()
+	} else {
+		p.Pause()
+	}
+}
+
+func (p *Player) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// Moves playback to the given offset, clamped to the recording.
+func (p *Player) Seek(at time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seek(at)
+}
+
+// Moves playback by d relative to the current position.
+func (p *Player) Skip(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seek(p.position() + d)
+}
+
+func (p *Player) seek(at time.Duration) {
+	if at < 0 {
+		at = 0
+	}
+	if at > p.Duration() {
+		at = p.Duration()
+	}
+	p.pos = sort.Search(len(p.offsets), func(i int) bool {
+		return p.offsets[i] >= at
+	})
+	if p.paused {
+		p.pauseAt = at
+	}
+	p.notify(at)
+}
+
+// Calls emit with every record at its scheduled time.
+// Returns when the recording ends (unless looping), emit fails or ctx is cancelled.
+func (p *Player) Play(ctx context.Context, emit func(rec recorder.Record) error) error {
+	p.mu.Lock()
+	p.notify(p.position())
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		p.mu.Lock()
+		if p.pos >= len(p.records) {
+			if !p.loop || len(p.records) == 0 {
+				p.mu.Unlock()
+				return nil
+			}
+			p.pos = 0
+			p.notify(0)
+		}
+		if p.paused {
+			p.mu.Unlock()
+			select {
+			case <-p.changed:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		gen := p.gen
+		rec := p.records[p.pos]
+		wait := time.Duration(float64(p.offsets[p.pos]-p.anchorAt)/p.speed) - time.Since(p.anchor)
+		p.mu.Unlock()
+
+		if wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-p.changed:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		p.mu.Lock()
+		if gen != p.gen {
+			p.mu.Unlock()
+			continue
+		}
+		p.pos++
+		p.mu.Unlock()
+
+		err := emit(rec)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Plays the recording by sending each datagram unchanged over conn.
+func (p *Player) ToUDP(ctx context.Context, conn net.Conn) error {
+	return p.Play(ctx, func(rec recorder.Record) error {
+		_, err := conn.Write(rec.Data)
+		return err
+	})
+}
+
+// Plays the recording by decoding each datagram and sending it through ch.
+// Datagrams that cannot be decoded are skipped.
+func (p *Player) ToChannel(ctx context.Context, ch chan<- decoder.Packet) error {
+	return p.Play(ctx, func(rec recorder.Record) error {
+		packet, err := p.header.Decode(rec.Data)
+		if err != nil {
+			return nil
+		}
+		select {
+		case ch <- packet:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
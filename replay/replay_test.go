@@ -0,0 +1,201 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel/recorder"
+)
+
+// Returns a player of n one-byte records, spacing apart. Record i holds i.
+func newTestPlayer(n int, spacing time.Duration) *Player {
+	h := recorder.Header{Start: time.Unix(1700000000, 0)}
+	records := make([]recorder.Record, n)
+	for i := range records {
+		records[i] = recorder.Record{Time: h.Start.Add(time.Duration(i) * spacing), Data: []byte{byte(i)}}
+	}
+	return NewPlayer(h, records)
+}
+
+// Plays p to the end and returns the records emitted and how long it took.
+func playAll(t *testing.T, p *Player, emit func(i int)) ([]int, time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var got []int
+	start := time.Now()
+	err := p.Play(ctx, func(rec recorder.Record) error {
+		got = append(got, int(rec.Data[0]))
+		if emit != nil {
+			emit(int(rec.Data[0]))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got, time.Since(start)
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlayKeepsTiming(t *testing.T) {
+	p := newTestPlayer(5, 20*time.Millisecond)
+	got, took := playAll(t, p, nil)
+	if !equal(got, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("got records %v", got)
+	}
+	if took < 80*time.Millisecond {
+		t.Fatalf("played 80ms of records in %v", took)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	p := newTestPlayer(6, 40*time.Millisecond)
+	p.SetSpeed(4)
+	p.SetSpeed(0)
+	if p.Speed() != 4 {
+		t.Fatalf("speed %v, want 4", p.Speed())
+	}
+	got, took := playAll(t, p, nil)
+	if len(got) != 6 {
+		t.Fatalf("got records %v", got)
+	}
+	// 200ms of records at 4x take 50ms.
+	if took < 45*time.Millisecond || took > 150*time.Millisecond {
+		t.Fatalf("played 200ms of records at 4x in %v", took)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	p := newTestPlayer(5, 10*time.Millisecond)
+	// Paused, the position stays where it was sought to.
+	p.Pause()
+	p.Seek(-time.Second)
+	if p.Position() != 0 {
+		t.Fatalf("position %v after seeking before the start", p.Position())
+	}
+	p.Seek(time.Hour)
+	if p.Position() != p.Duration() {
+		t.Fatalf("position %v after seeking past the end, want %v", p.Position(), p.Duration())
+	}
+
+	p.Seek(25 * time.Millisecond)
+	p.Resume()
+	got, _ := playAll(t, p, nil)
+	if !equal(got, []int{3, 4}) {
+		t.Fatalf("got records %v after seeking to 25ms, want [3 4]", got)
+	}
+}
+
+func TestSkipBackWhilePlaying(t *testing.T) {
+	p := newTestPlayer(5, 10*time.Millisecond)
+	skipped := false
+	got, _ := playAll(t, p, func(i int) {
+		if i == 3 && !skipped {
+			skipped = true
+			// Pin the position so the skip does not depend on scheduling.
+			p.Pause()
+			p.Seek(30 * time.Millisecond)
+			p.Skip(-25 * time.Millisecond)
+			p.Resume()
+		}
+	})
+	// Back from 30ms to 5ms, so record 1 is next.
+	if !equal(got, []int{0, 1, 2, 3, 1, 2, 3, 4}) {
+		t.Fatalf("got records %v", got)
+	}
+}
+
+func TestPause(t *testing.T) {
+	p := newTestPlayer(4, 5*time.Millisecond)
+	emitted := make(chan int, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Play(context.Background(), func(rec recorder.Record) error {
+			if rec.Data[0] == 1 {
+				p.Pause()
+			}
+			emitted <- int(rec.Data[0])
+			return nil
+		})
+	}()
+
+	for want := 0; want < 2; want++ {
+		if got := <-emitted; got != want {
+			t.Fatalf("got record %d, want %d", got, want)
+		}
+	}
+	at := p.Position()
+	select {
+	case i := <-emitted:
+		t.Fatalf("record %d played while paused", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !p.Paused() || p.Position() != at {
+		t.Fatalf("position moved from %v to %v while paused", at, p.Position())
+	}
+
+	p.TogglePause()
+	for want := 2; want < 4; want++ {
+		if got := <-emitted; got != want {
+			t.Fatalf("got record %d after resuming, want %d", got, want)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	p := newTestPlayer(3, time.Millisecond)
+	p.SetLoop(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var got []int
+	stop := errors.New("stop")
+	err := p.Play(ctx, func(rec recorder.Record) error {
+		got = append(got, int(rec.Data[0]))
+		if len(got) == 7 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("got %v, want the emit error", err)
+	}
+	if !equal(got, []int{0, 1, 2, 0, 1, 2, 0}) {
+		t.Fatalf("got records %v", got)
+	}
+}
+
+func TestPlayEmpty(t *testing.T) {
+	p := newTestPlayer(0, 0)
+	p.SetLoop(true)
+	if got, _ := playAll(t, p, nil); len(got) != 0 {
+		t.Fatalf("got records %v", got)
+	}
+}
+
+func TestPlayCancelled(t *testing.T) {
+	p := newTestPlayer(2, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := p.Play(ctx, func(recorder.Record) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
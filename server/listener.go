@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+// DecodeError is reported for datagrams that could not be decoded.
+type DecodeError struct {
+	Addr net.Addr
+	Size int
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding %d byte datagram from %s: %v", e.Size, e.Addr, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Listener reads telemetry datagrams from a connection until its context is cancelled.
+type Listener struct {
+	conn net.PacketConn
+	// Packet layout to decode. Auto detects it from the datagram length.
+	Format decoder.Format
+	// Called with every read or decode error. Rejected datagrams are never sent as packets.
+	OnError func(err error)
+	// Called with every datagram read, unchanged and before it is decoded, so
+	// datagrams the decoder rejects are passed on too.
+	// The slice is only valid until OnDatagram returns.
+	OnDatagram func(b []byte, at time.Time)
+}
+
+func NewListener(conn net.PacketConn, format decoder.Format) *Listener {
+	return &Listener{
+		conn:   conn,
+		Format: format,
+	}
+}
+
+func (l *Listener) report(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+// Bounds of the wait after a read error, doubled for every error in a row.
+const (
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+// Reads and decodes datagrams and sends them through ch. Each packet carries
+// the layout it was decoded from, so fields the source does not send can be
+// told apart from fields that are zero.
+// Returns the context error once ctx is cancelled, or the read error if the connection is closed.
+func (l *Listener) Listen(ctx context.Context, ch chan<- decoder.Packet) error {
+	// Clear a deadline left by an earlier Listen on the same connection.
+	l.conn.SetReadDeadline(time.Time{})
+	// Unblock the pending read as soon as the context is done.
+	fired := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		l.conn.SetReadDeadline(time.Now())
+		close(fired)
+	})
+	defer func() {
+		if !stop() {
+			<-fired
+		}
+		l.conn.SetReadDeadline(time.Time{})
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	var backoff time.Duration
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			l.report(err)
+			// Wait before reading again so a persistent error does not spin.
+			backoff = min(max(2*backoff, minReadBackoff), maxReadBackoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		backoff = 0
+
+		if l.OnDatagram != nil {
+			l.OnDatagram(buf[:n], time.Now())
+		}
+		packet, err := decoder.Decode(buf[:n], l.Format)
+		if err != nil {
+			l.report(&DecodeError{Addr: addr, Size: n, Err: err})
+			continue
+		}
+
+		select {
+		case ch <- packet:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
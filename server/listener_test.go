@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel/decoder"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, to net.Addr, b []byte) {
+	t.Helper()
+	c, err := net.Dial("udp4", to.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListenReusesConnection(t *testing.T) {
+	conn := listenUDP(t)
+	l := NewListener(conn, decoder.Auto)
+	ch := make(chan decoder.Packet, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Listen(ctx, ch); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	// A second Listen on the same connection must not see the old deadline.
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- l.Listen(ctx, ch) }()
+
+	send(t, conn.LocalAddr(), make([]byte, decoder.SledSize))
+	select {
+	case p := <-ch:
+		if p.Format != decoder.Sled {
+			t.Fatalf("format %s, want sled", p.Format)
+		}
+	case err := <-done:
+		t.Fatalf("Listen returned %v before a packet arrived", err)
+	case <-ctx.Done():
+		t.Fatal("no packet received")
+	}
+	cancel()
+	<-done
+}
+
+// failingConn fails every read without blocking.
+type failingConn struct {
+	net.PacketConn
+	reads atomic.Int64
+}
+
+func (c *failingConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.reads.Add(1)
+	return 0, nil, errors.New("read failed")
+}
+
+func (c *failingConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func TestListenBacksOffOnErrors(t *testing.T) {
+	conn := &failingConn{}
+	l := NewListener(conn, decoder.Auto)
+	var reported atomic.Int64
+	l.OnError = func(error) { reported.Add(1) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	l.Listen(ctx, make(chan decoder.Packet))
+
+	// 10, 20, 40, 80 and 160 ms fit in 300 ms, so at most a handful of reads.
+	if n := conn.reads.Load(); n > 10 {
+		t.Fatalf("%d reads in 300ms, expected the listener to back off", n)
+	}
+	if reported.Load() != conn.reads.Load() {
+		t.Fatalf("%d errors reported for %d reads", reported.Load(), conn.reads.Load())
+	}
+}
+
+func TestListenPassesUndecodableDatagrams(t *testing.T) {
+	conn := listenUDP(t)
+	l := NewListener(conn, decoder.Auto)
+	got := make(chan int, 2)
+	l.OnDatagram = func(b []byte, _ time.Time) { got <- len(b) }
+	decodeErrs := make(chan error, 1)
+	l.OnError = func(err error) { decodeErrs <- err }
+	ch := make(chan decoder.Packet, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- l.Listen(ctx, ch) }()
+
+	send(t, conn.LocalAddr(), make([]byte, 100))
+	select {
+	case n := <-got:
+		if n != 100 {
+			t.Fatalf("OnDatagram got %d bytes, want 100", n)
+		}
+	case <-ctx.Done():
+		t.Fatal("undecodable datagram not passed to OnDatagram")
+	}
+	var decodeErr *DecodeError
+	if err := <-decodeErrs; !errors.As(err, &decodeErr) {
+		t.Fatalf("got %v, want a DecodeError", err)
+	}
+	cancel()
+	<-done
+}
@@ -1,28 +1,39 @@
 package server
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
 	"net"
 
 	"github.com/charmbracelet/log"
 	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
 )
 
+// Large enough for every known packet layout.
+const maxDatagramSize = 1024
+
 // Reads telemetry data packets and returns them through provided channel.
+// The packet layout is detected from the datagram length.
 func ReadPackets(conn net.PacketConn, ch chan fmtel.ForzaPacket) {
-	buf := make([]byte, binary.Size(fmtel.ForzaPacket{}))
-	for {
-		_, _, err := conn.ReadFrom(buf)
-		if err != nil {
-			log.Error(err)
-		}
-		var packet fmtel.ForzaPacket
-		err = binary.Read(bytes.NewReader(buf), binary.LittleEndian, &packet)
-		if err != nil {
-			log.Error(err)
-		}
-		ch <- packet
+	ReadPacketsWithFormat(conn, ch, decoder.Auto)
+}
 
+// Reads telemetry data packets in the given layout and returns them through provided channel.
+// Errors are logged and the datagram is dropped. Returns when the connection is closed.
+func ReadPacketsWithFormat(conn net.PacketConn, ch chan fmtel.ForzaPacket, format decoder.Format) {
+	l := NewListener(conn, format)
+	l.OnError = func(err error) {
+		log.Error(err)
+	}
+	packets := make(chan decoder.Packet)
+	go func() {
+		for p := range packets {
+			ch <- p.ForzaPacket
+		}
+	}()
+	err := l.Listen(context.Background(), packets)
+	close(packets)
+	if err != nil {
+		log.Error(err)
 	}
 }
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/hub"
+)
+
+// Rate at which the game sends packets.
+const NativeRate = 60
+
+const wsWriteTimeout = 5 * time.Second
+
+type Encoding uint8
+
+const (
+	JSON Encoding = iota
+	Binary
+)
+
+// Offset and size of a field in the encoded packet, plus its struct index.
+type fieldInfo struct {
+	name   string
+	index  int
+	offset int
+	size   int
+}
+
+var packetFields = func() map[string]fieldInfo {
+	fields := make(map[string]fieldInfo)
+	t := reflect.TypeOf(fmtel.ForzaPacket{})
+	offset := 0
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		size := int(f.Type.Size())
+		fields[f.Name] = fieldInfo{name: f.Name, index: i, offset: offset, size: size}
+		offset += size
+	}
+	return fields
+}()
+
+// Per-client stream options, parsed from the query string:
+//
+//	rate     packets per second, up to NativeRate (default NativeRate)
+//	fields   comma separated ForzaPacket field names (default all)
+//	encoding "json" or "binary" (default json)
+//
+// Binary frames hold the selected fields little-endian in the requested order,
+// or the full packet when no fields are selected. JSON frames leave out fields
+// the source does not send; binary frames have them as zero.
+type StreamOptions struct {
+	Rate     int
+	Fields   []string
+	Encoding Encoding
+}
+
+func ParseStreamOptions(r *http.Request) (StreamOptions, error) {
+	q := r.URL.Query()
+	opts := StreamOptions{Rate: NativeRate}
+
+	if s := q.Get("rate"); s != "" {
+		rate, err := strconv.Atoi(s)
+		if err != nil || rate <= 0 {
+			return opts, fmt.Errorf("invalid rate %q", s)
+		}
+		opts.Rate = min(rate, NativeRate)
+	}
+
+	if s := q.Get("fields"); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := packetFields[name]; !ok {
+				return opts, fmt.Errorf("unknown field %q", name)
+			}
+			opts.Fields = append(opts.Fields, name)
+		}
+	}
+
+	switch q.Get("encoding") {
+	case "", "json":
+		opts.Encoding = JSON
+	case "binary":
+		opts.Encoding = Binary
+	default:
+		return opts, fmt.Errorf("invalid encoding %q", q.Get("encoding"))
+	}
+	return opts, nil
+}
+
+// Encodes a packet according to the options. Returns the websocket message type and payload.
+func (o *StreamOptions) Encode(p *decoder.Packet) (int, []byte, error) {
+	if o.Encoding == Binary {
+		var full [fmtel.PacketSize]byte
+		p.PutBinary(full[:])
+		if len(o.Fields) == 0 {
+			return websocket.BinaryMessage, full[:], nil
+		}
+		b := make([]byte, 0, fmtel.PacketSize)
+		for _, name := range o.Fields {
+			f := packetFields[name]
+			b = append(b, full[f.offset:f.offset+f.size]...)
+		}
+		return websocket.BinaryMessage, b, nil
+	}
+
+	if len(o.Fields) == 0 {
+		b, err := p.ToJson()
+		return websocket.TextMessage, b, err
+	}
+	v := reflect.ValueOf(&p.ForzaPacket).Elem()
+	m := make(map[string]any, len(o.Fields))
+	for _, name := range o.Fields {
+		if !p.HasField(name) {
+			continue
+		}
+		m[name] = v.Field(packetFields[name].index).Interface()
+	}
+	b, err := json.Marshal(m)
+	return websocket.TextMessage, b, err
+}
+
+type wsClient struct {
+	conn *websocket.Conn
+	opts StreamOptions
+	sub  *hub.Subscription
+}
+
+// Sends every packet as it arrives if tick is nil, otherwise the newest packet on each tick.
+func (c *wsClient) writeLoop(done <-chan struct{}, tick <-chan time.Time) {
+	var pending decoder.Packet
+	var hasPending bool
+	for {
+		select {
+		case <-done:
+			return
+		case p, ok := <-c.sub.C:
+			if !ok {
+				return
+			}
+			if tick != nil {
+				pending, hasPending = p, true
+				continue
+			}
+			if !c.write(&p) {
+				return
+			}
+		case <-tick:
+			if !hasPending {
+				continue
+			}
+			hasPending = false
+			if !c.write(&pending) {
+				return
+			}
+		}
+	}
+}
+
+// Returns false when the connection failed.
+func (c *wsClient) write(p *decoder.Packet) bool {
+	kind, b, err := c.opts.Encode(p)
+	if err != nil {
+		return true
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteMessage(kind, b) == nil
+}
+
+// WebSocketHandler streams packets published on a hub to websocket clients.
+// Each client gets its own rate, field selection and encoding. Slow clients
+// drop frames instead of holding up the others.
+type WebSocketHandler struct {
+	upgrader websocket.Upgrader
+	hub      *hub.Hub
+	clients  atomic.Int64
+}
+
+func NewWebSocketHandler(h *hub.Hub) *WebSocketHandler {
+	return &WebSocketHandler{
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		hub: h,
+	}
+}
+
+func (h *WebSocketHandler) ClientCount() int {
+	return int(h.clients.Load())
+}
+
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	opts, err := ParseStreamOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &wsClient{conn: conn, opts: opts, sub: h.hub.Subscribe(1, hub.DropOldest)}
+	defer c.sub.Close()
+	h.clients.Add(1)
+	defer h.clients.Add(-1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Nothing is expected from clients, but reading handles pings and closes.
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Below the native rate the newest packet is sent on every tick, so the
+	// client gets the rate it asked for whatever the phase of the game's packets.
+	var tick <-chan time.Time
+	if opts.Rate < NativeRate {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	c.writeLoop(done, tick)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stelmanjones/fmtel/decoder"
+	"github.com/stelmanjones/fmtel/hub"
+)
+
+// Dials a server that runs a client write loop fed from packets and tick.
+func dialWriteLoop(t *testing.T, packets <-chan decoder.Packet, tick <-chan time.Time) *websocket.Conn {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		opts := StreamOptions{Rate: 30, Fields: []string{"TimestampMS"}}
+		c := &wsClient{conn: conn, opts: opts, sub: &hub.Subscription{C: packets}}
+		c.writeLoop(r.Context().Done(), tick)
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readTimestamp(t *testing.T, conn *websocket.Conn) uint32 {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, b, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m struct{ TimestampMS uint32 }
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m.TimestampMS
+}
+
+func TestWebSocketRate(t *testing.T) {
+	// Unbuffered, so every send is handled before the next one.
+	packets := make(chan decoder.Packet)
+	tick := make(chan time.Time)
+	conn := dialWriteLoop(t, packets, tick)
+
+	publish := func(ts uint32) {
+		p := decoder.Packet{Format: decoder.Motorsport, Fields: decoder.SledFields}
+		p.TimestampMS = ts
+		packets <- p
+	}
+
+	// Packets between ticks are coalesced into the newest one.
+	for i := uint32(1); i <= 3; i++ {
+		publish(i)
+	}
+	tick <- time.Now()
+	if ts := readTimestamp(t, conn); ts != 3 {
+		t.Fatalf("got packet %d on the first tick, want 3", ts)
+	}
+
+	// A tick without a new packet sends nothing, so the next frame is the next packet.
+	tick <- time.Now()
+	publish(4)
+	tick <- time.Now()
+	if ts := readTimestamp(t, conn); ts != 4 {
+		t.Fatalf("got packet %d on the third tick, want 4", ts)
+	}
+}
+
+func TestWebSocketNativeRate(t *testing.T) {
+	h := hub.New()
+	defer h.Close()
+	handler := NewWebSocketHandler(h)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?fields=TimestampMS", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for handler.ClientCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// At the native rate every packet is sent as it arrives.
+	p := decoder.Packet{Format: decoder.Motorsport, Fields: decoder.SledFields}
+	p.TimestampMS = 7
+	h.Publish(p)
+	if ts := readTimestamp(t, conn); ts != 7 {
+		t.Fatalf("got packet %d, want 7", ts)
+	}
+}
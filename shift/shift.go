@@ -0,0 +1,184 @@
+package shift
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/dyno"
+	"github.com/stelmanjones/fmtel/gearing"
+)
+
+// Step in rpm used when searching for the shift point.
+const rpmStep = 25
+
+// How often in milliseconds the advisor recomputes the shift points.
+const recomputeInterval = 1000
+
+// Point is the rpm at which to shift up from a gear.
+type Point struct {
+	Gear int     `json:"gear"`
+	Rpm  float32 `json:"rpm"`
+	// True if the next gear never gives more power before the redline or the
+	// end of the power curve, so the point is the redline.
+	Redline bool `json:"redline"`
+}
+
+// Computes the upshift point of every gear that has a known ratio and a next
+// gear with a known ratio. ratios holds the overall ratio of each gear, or any
+// value proportional to it. Upshifting pays off
+// once the next gear, at its lower rpm for the same speed, makes more power
+// and so more force at the wheels.
+func Compute(c *dyno.Curve, ratios map[int]float32, redline float32) []Point {
+	if c == nil || len(c.Bins) == 0 {
+		return nil
+	}
+	top := c.Bins[len(c.Bins)-1].Rpm + c.BinWidth
+	if redline <= 0 || redline > top {
+		redline = top
+	}
+
+	gears := make([]int, 0, len(ratios))
+	for g := range ratios {
+		gears = append(gears, g)
+	}
+	sort.Ints(gears)
+
+	var points []Point
+	for _, g := range gears {
+		next, ok := ratios[g+1]
+		if !ok || ratios[g] <= 0 || next <= 0 || next >= ratios[g] {
+			continue
+		}
+		drop := next / ratios[g]
+
+		point := Point{Gear: g, Rpm: redline, Redline: true}
+		for rpm := c.Bins[0].Rpm; rpm <= redline; rpm += rpmStep {
+			now, _, ok := c.At(rpm)
+			if !ok {
+				continue
+			}
+			after, _, ok := c.At(rpm * drop)
+			if !ok {
+				continue
+			}
+			if after > now {
+				point = Point{Gear: g, Rpm: rpm}
+				break
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// Advisor keeps the shift points of the current car and tune up to date from
+// its dyno curve and gear ratios. It is safe for concurrent use.
+type Advisor struct {
+	mu      sync.Mutex
+	dyno    *dyno.Dyno
+	gearing *gearing.Analyzer
+	key     dyno.Key
+	points  []Point
+	last    fmtel.ForzaPacket
+	hasLast bool
+	updated uint32
+}
+
+// Returns an advisor that takes the gear ratios from g, which the caller feeds.
+func NewAdvisor(d *dyno.Dyno, g *gearing.Analyzer) *Advisor {
+	return &Advisor{dyno: d, gearing: g}
+}
+
+// Feeds a packet to the advisor. The dyno and the gearing analyzer should be
+// fed the same packets first.
+// Only packets sent while a race is on should be passed in.
+func (a *Advisor) Update(p *fmtel.ForzaPacket) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := dyno.KeyOf(p)
+	if !a.hasLast || key != a.key {
+		a.key = key
+		a.points = nil
+		a.updated = p.TimestampMS - recomputeInterval
+	}
+	a.last = *p
+	a.hasLast = true
+
+	if p.TimestampMS-a.updated < recomputeInterval {
+		return
+	}
+	a.updated = p.TimestampMS
+	curve, ok := a.dyno.Current()
+	if !ok {
+		return
+	}
+	a.points = Compute(curve, a.gearing.Ratios(), p.EngineMaxRpm)
+}
+
+// Returns the shift points of the current car and tune.
+func (a *Advisor) Points() []Point {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Point(nil), a.points...)
+}
+
+// Returns the shift point of a gear.
+func (a *Advisor) Rpm(gear int) (float32, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range a.points {
+		if p.Gear == gear {
+			return p.Rpm, true
+		}
+	}
+	return 0, false
+}
+
+// Status is the shift advice for the latest packet, for external shift lights.
+type Status struct {
+	dyno.Key
+	Gear     int     `json:"gear"`
+	Rpm      float32 `json:"rpm"`
+	MaxRpm   float32 `json:"max_rpm"`
+	IdleRpm  float32 `json:"idle_rpm"`
+	ShiftRpm float32 `json:"shift_rpm"`
+	// True once the rpm has reached the shift point of the current gear.
+	Shift  bool    `json:"shift"`
+	Points []Point `json:"points"`
+}
+
+// Returns the shift advice for the latest packet.
+func (a *Advisor) Status() Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := Status{
+		Key:     a.key,
+		Gear:    int(a.last.Gear),
+		Rpm:     a.last.CurrentEngineRpm,
+		MaxRpm:  a.last.EngineMaxRpm,
+		IdleRpm: a.last.EngineIdleRpm,
+		Points:  append([]Point{}, a.points...),
+	}
+	for _, p := range a.points {
+		if p.Gear == s.Gear {
+			s.ShiftRpm = p.Rpm
+			s.Shift = s.Rpm >= p.Rpm
+		}
+	}
+	return s
+}
+
+// Serves the shift advice as JSON.
+func (a *Advisor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Status())
+}
@@ -0,0 +1,135 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/laps"
+)
+
+// Number of recent laps the rolling fuel average covers.
+const DefaultWindow = 5
+
+// Race is the length of a race, either in laps or in time. Laps wins if both are set.
+type Race struct {
+	Laps     int
+	Duration time.Duration
+}
+
+// Returns true if a race length is set.
+func (r Race) IsSet() bool {
+	return r.Laps > 0 || r.Duration > 0
+}
+
+// Burn is the fuel used per lap as a fraction of the tank.
+type Burn struct {
+	Average float32
+	Worst   float32
+	// Average lap time over the same laps.
+	LapTime time.Duration
+	// Number of laps the figures are based on.
+	Laps int
+}
+
+// Returns the fuel burn over the last window laps. Laps that gained fuel,
+// such as a lap with a pit stop, are skipped.
+func FuelBurn(history []laps.Lap, window int) (Burn, bool) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	var b Burn
+	var sum float32
+	var lapTime time.Duration
+	for i := len(history) - 1; i >= 0 && b.Laps < window; i-- {
+		l := history[i]
+		if l.FuelUsed <= 0 || l.Time <= 0 {
+			continue
+		}
+		sum += l.FuelUsed
+		lapTime += l.Time
+		if l.FuelUsed > b.Worst {
+			b.Worst = l.FuelUsed
+		}
+		b.Laps++
+	}
+	if b.Laps == 0 {
+		return Burn{}, false
+	}
+	b.Average = sum / float32(b.Laps)
+	b.LapTime = lapTime / time.Duration(b.Laps)
+	return b, true
+}
+
+// Plan is the fuel strategy for the rest of a race.
+type Plan struct {
+	Burn Burn
+	// Fuel in the tank as a fraction.
+	Fuel float32
+	// Laps the fuel in the tank lasts at average and worst case burn.
+	FuelLaps      float32
+	FuelLapsWorst float32
+	// Laps left in the race, counting the rest of the current one.
+	// Zero if no race length is set.
+	RaceLaps float32
+	// Fuel to add to finish at worst case burn, as a fraction of the tank.
+	// More than 1 means more than one stop.
+	FuelToAdd float32
+	// Minimum number of stops needed to finish.
+	Stops int
+	// Last lap to pit at the end of, counting from 1 like the lap shown in game.
+	// Only set if Stops > 0.
+	PitLap int
+}
+
+// Returns the fuel strategy from the current packet and the completed laps.
+// Returns false until a lap with fuel use has been completed.
+func (r Race) Plan(p *fmtel.ForzaPacket, history []laps.Lap, window int) (Plan, bool) {
+	burn, ok := FuelBurn(history, window)
+	if !ok {
+		return Plan{}, false
+	}
+
+	plan := Plan{
+		Burn:          burn,
+		Fuel:          p.Fuel,
+		FuelLaps:      p.Fuel / burn.Average,
+		FuelLapsWorst: p.Fuel / burn.Worst,
+	}
+
+	// Position in laps since the start, including the part of the current lap done.
+	progress := float32(math.Min(float64(p.CurrentLap)/burn.LapTime.Seconds(), 1))
+	position := float32(p.LapNumber) + progress
+
+	switch {
+	case r.Laps > 0:
+		plan.RaceLaps = float32(r.Laps) - position
+	case r.Duration > 0:
+		left := r.Duration.Seconds() - float64(p.CurrentRaceTime)
+		// The race ends with the lap during which the clock runs out.
+		end := math.Ceil(float64(progress) + math.Max(left, 0)/burn.LapTime.Seconds())
+		plan.RaceLaps = float32(end) - progress
+	default:
+		return plan, true
+	}
+	if plan.RaceLaps < 0 {
+		plan.RaceLaps = 0
+	}
+
+	plan.FuelToAdd = plan.RaceLaps*burn.Worst - p.Fuel
+	if plan.FuelToAdd <= 0 {
+		plan.FuelToAdd = 0
+		return plan, true
+	}
+	plan.Stops = int(math.Ceil(float64(plan.FuelToAdd)))
+
+	// Pit as late as the fuel allows: at the end of the last lap it lasts.
+	// LapNumber counts completed laps, so the current lap is LapNumber+1.
+	last := int(math.Floor(float64(position + plan.FuelLapsWorst)))
+	if last <= int(p.LapNumber) {
+		last = int(p.LapNumber) + 1
+	}
+	plan.PitLap = last
+	return plan, true
+}
@@ -0,0 +1,101 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/laps"
+)
+
+// Returns n laps of a minute that each use burn of the tank.
+func history(n int, burn float32) []laps.Lap {
+	var h []laps.Lap
+	for i := 0; i < n; i++ {
+		h = append(h, laps.Lap{Number: uint16(i), Time: time.Minute, Valid: true, FuelUsed: burn})
+	}
+	return h
+}
+
+func TestPlanFuelLimited(t *testing.T) {
+	// At the start of lap 4 of 6 with fuel for two more laps.
+	p := fmtel.ForzaPacket{LapNumber: 3, Fuel: 0.5}
+	plan, ok := Race{Laps: 6}.Plan(&p, history(3, 0.25), 0)
+	if !ok {
+		t.Fatal("no plan")
+	}
+	if plan.RaceLaps != 3 || plan.FuelLapsWorst != 2 {
+		t.Errorf("race laps %v, fuel laps %v, want 3 and 2", plan.RaceLaps, plan.FuelLapsWorst)
+	}
+	if plan.Stops != 1 || plan.FuelToAdd != 0.25 {
+		t.Errorf("stops %d, fuel to add %v, want 1 and 0.25", plan.Stops, plan.FuelToAdd)
+	}
+	// The fuel lasts laps 4 and 5.
+	if plan.PitLap != 5 {
+		t.Errorf("pit lap %d, want 5", plan.PitLap)
+	}
+}
+
+func TestPlanPitThisLap(t *testing.T) {
+	// Halfway through lap 2 with fuel for less than the rest of it.
+	p := fmtel.ForzaPacket{LapNumber: 1, CurrentLap: 30, Fuel: 0.1}
+	plan, ok := Race{Laps: 5}.Plan(&p, history(1, 0.25), 0)
+	if !ok {
+		t.Fatal("no plan")
+	}
+	if plan.Stops != 1 || plan.PitLap != 2 {
+		t.Errorf("stops %d, pit lap %d, want 1 and 2", plan.Stops, plan.PitLap)
+	}
+}
+
+func TestPlanNotFuelLimited(t *testing.T) {
+	p := fmtel.ForzaPacket{LapNumber: 3, Fuel: 1}
+	plan, ok := Race{Laps: 6}.Plan(&p, history(3, 0.25), 0)
+	if !ok {
+		t.Fatal("no plan")
+	}
+	if plan.Stops != 0 || plan.FuelToAdd != 0 || plan.PitLap != 0 {
+		t.Errorf("stops %d, fuel to add %v, pit lap %d, want none", plan.Stops, plan.FuelToAdd, plan.PitLap)
+	}
+	if plan.FuelLaps != 4 {
+		t.Errorf("fuel laps %v, want 4", plan.FuelLaps)
+	}
+}
+
+func TestPlanDuration(t *testing.T) {
+	// Ten minutes into a 30 minute race of one minute laps.
+	p := fmtel.ForzaPacket{LapNumber: 10, CurrentRaceTime: 600, Fuel: 1}
+	plan, ok := Race{Duration: 30 * time.Minute}.Plan(&p, history(10, 0.1), 0)
+	if !ok {
+		t.Fatal("no plan")
+	}
+	if plan.RaceLaps != 20 {
+		t.Errorf("race laps %v, want 20", plan.RaceLaps)
+	}
+	if plan.Stops != 1 || plan.PitLap != 20 {
+		t.Errorf("stops %d, pit lap %d, want 1 and 20", plan.Stops, plan.PitLap)
+	}
+}
+
+func TestPlanNoValidLaps(t *testing.T) {
+	p := fmtel.ForzaPacket{LapNumber: 1, Fuel: 0.5}
+	if _, ok := (Race{Laps: 6}).Plan(&p, nil, 0); ok {
+		t.Error("plan without laps")
+	}
+	// A lap with a pit stop gains fuel and is skipped.
+	pit := []laps.Lap{{Number: 0, Time: time.Minute, FuelUsed: -0.5}}
+	if _, ok := (Race{Laps: 6}).Plan(&p, pit, 0); ok {
+		t.Error("plan from a lap that gained fuel")
+	}
+}
+
+func TestFuelBurnWindow(t *testing.T) {
+	h := append(history(3, 0.5), history(2, 0.25)...)
+	b, ok := FuelBurn(h, 2)
+	if !ok {
+		t.Fatal("no burn")
+	}
+	if b.Laps != 2 || b.Average != 0.25 || b.Worst != 0.25 || b.LapTime != time.Minute {
+		t.Errorf("burn %+v, want the last two laps", b)
+	}
+}
@@ -0,0 +1,193 @@
+package tires
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stelmanjones/fmtel"
+	"github.com/stelmanjones/fmtel/units"
+)
+
+var ErrInvalidWindow = errors.New("invalid temperature window")
+
+// Status is where a tire temperature sits relative to its window.
+type Status int
+
+const (
+	Cold Status = iota
+	Optimal
+	Hot
+)
+
+func (s Status) String() string {
+	switch s {
+	case Cold:
+		return "cold"
+	case Optimal:
+		return "optimal"
+	case Hot:
+		return "hot"
+	default:
+		return "unknown"
+	}
+}
+
+// Window is an optimal tire temperature range in fahrenheit, like the packet.
+type Window struct {
+	Min float32
+	Max float32
+}
+
+// Returns a window from temperatures in celsius.
+func CelsiusWindow(min, max float32) Window {
+	return Window{Min: min*9/5 + 32, Max: max*9/5 + 32}
+}
+
+// Used when no window is configured.
+var DefaultWindow = CelsiusWindow(70, 100)
+
+// Returns the status of a temperature in fahrenheit.
+func (w Window) Status(temp float32) Status {
+	switch {
+	case temp < w.Min:
+		return Cold
+	case temp > w.Max:
+		return Hot
+	default:
+		return Optimal
+	}
+}
+
+// Parses a window such as "70-100" given in unit.
+func ParseWindow(s string, unit units.Temperature) (Window, error) {
+	lo, hi, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("%w: %q", ErrInvalidWindow, s)
+	}
+	min, err := strconv.ParseFloat(strings.TrimSpace(lo), 32)
+	if err != nil {
+		return Window{}, fmt.Errorf("%w: %q", ErrInvalidWindow, s)
+	}
+	max, err := strconv.ParseFloat(strings.TrimSpace(hi), 32)
+	if err != nil || max <= min {
+		return Window{}, fmt.Errorf("%w: %q", ErrInvalidWindow, s)
+	}
+	if unit == units.CELSIUS {
+		return CelsiusWindow(float32(min), float32(max)), nil
+	}
+	return Window{Min: float32(min), Max: float32(max)}, nil
+}
+
+// Windows holds a global window and optional windows per compound.
+// The packet does not say which compound is fitted, so it is chosen by the user.
+type Windows struct {
+	Default   Window
+	Compounds map[string]Window
+}
+
+// Returns the window for a compound, or the global one.
+func (w Windows) For(compound string) Window {
+	if win, ok := w.Compounds[compound]; ok {
+		return win
+	}
+	if w.Default == (Window{}) {
+		return DefaultWindow
+	}
+	return w.Default
+}
+
+// Sets a window from "min-max" or "compound=min-max" given in unit.
+func (w *Windows) Set(s string, unit units.Temperature) error {
+	compound, value, ok := strings.Cut(s, "=")
+	if !ok {
+		value = compound
+	}
+	win, err := ParseWindow(value, unit)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		w.Default = win
+		return nil
+	}
+	if w.Compounds == nil {
+		w.Compounds = make(map[string]Window)
+	}
+	w.Compounds[compound] = win
+	return nil
+}
+
+// Returns the temperature status of every corner.
+func TempStatus(p *fmtel.ForzaPacket, w Window) [4]Status {
+	var status [4]Status
+	for i, t := range Temps(p) {
+		status[i] = w.Status(t)
+	}
+	return status
+}
+
+// Returns front minus rear and left minus right average temperature.
+func Balance(temps [4]float32) (frontRear, leftRight float32) {
+	frontRear = (temps[FrontLeft]+temps[FrontRight])/2 - (temps[RearLeft]+temps[RearRight])/2
+	leftRight = (temps[FrontLeft]+temps[RearLeft])/2 - (temps[FrontRight]+temps[RearRight])/2
+	return frontRear, leftRight
+}
+
+// Default span in milliseconds TempTrend looks back over.
+const DefaultTrendSpan = 3000
+
+type tempSample struct {
+	at    uint32
+	temps [4]float32
+}
+
+// TempTrend tracks how fast tire temperatures change over a short span.
+type TempTrend struct {
+	// Span to look back over in milliseconds.
+	Span    uint32
+	samples []tempSample
+}
+
+func NewTempTrend() *TempTrend {
+	return &TempTrend{Span: DefaultTrendSpan}
+}
+
+func (t *TempTrend) Update(p *fmtel.ForzaPacket) {
+	if n := len(t.samples); n > 0 {
+		last := t.samples[n-1].at
+		// Time went backwards or a long pause: start over.
+		if p.TimestampMS-last > 10*t.Span {
+			t.samples = t.samples[:0]
+		}
+	}
+	t.samples = append(t.samples, tempSample{at: p.TimestampMS, temps: Temps(p)})
+
+	drop := 0
+	for drop < len(t.samples)-1 && p.TimestampMS-t.samples[drop+1].at >= t.Span {
+		drop++
+	}
+	t.samples = t.samples[drop:]
+}
+
+// Returns the change per second of every corner in fahrenheit over the span.
+func (t *TempTrend) Rate() ([4]float32, bool) {
+	var rate [4]float32
+	if len(t.samples) < 2 {
+		return rate, false
+	}
+	first, last := t.samples[0], t.samples[len(t.samples)-1]
+	dt := float32(last.at-first.at) / 1000
+	if dt <= 0 {
+		return rate, false
+	}
+	for i := range rate {
+		rate[i] = (last.temps[i] - first.temps[i]) / dt
+	}
+	return rate, true
+}
+
+func (t *TempTrend) Reset() {
+	t.samples = nil
+}
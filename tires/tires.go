@@ -0,0 +1,223 @@
+package tires
+
+import (
+	"math"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Default wear (0 new, 1 fully worn) at which a tire counts as worn out.
+const DefaultWearLimit = 0.7
+
+// Corner is one of the four tires.
+type Corner int
+
+const (
+	FrontLeft Corner = iota
+	FrontRight
+	RearLeft
+	RearRight
+)
+
+// All corners in packet order.
+var Corners = [4]Corner{FrontLeft, FrontRight, RearLeft, RearRight}
+
+func (c Corner) String() string {
+	switch c {
+	case FrontLeft:
+		return "FL"
+	case FrontRight:
+		return "FR"
+	case RearLeft:
+		return "RL"
+	case RearRight:
+		return "RR"
+	default:
+		return "-"
+	}
+}
+
+// Returns the tire wear of the packet per corner.
+func Wear(p *fmtel.ForzaPacket) [4]float32 {
+	return [4]float32{p.TireWearFrontLeft, p.TireWearFrontRight, p.TireWearRearLeft, p.TireWearRearRight}
+}
+
+// Returns the tire temperatures of the packet per corner in fahrenheit.
+func Temps(p *fmtel.ForzaPacket) [4]float32 {
+	return [4]float32{p.TireTempFrontLeft, p.TireTempFrontRight, p.TireTempRearLeft, p.TireTempRearRight}
+}
+
+// Returns the combined slip of the packet per corner.
+func Slip(p *fmtel.ForzaPacket) [4]float32 {
+	return [4]float32{p.TireCombinedSlipFrontLeft, p.TireCombinedSlipFrontRight, p.TireCombinedSlipRearLeft, p.TireCombinedSlipRearRight}
+}
+
+// Wear every tire has to lose from one packet to the next to count as a new
+// set, so rewinds and noise in the wear reading do not end a stint.
+const newTireDrop = 0.05
+
+// Wear at or below which a tire that lost wear counts as new.
+const freshWear = 0.005
+
+// LapWear is the tire wear at the end of a lap.
+type LapWear struct {
+	// Laps completed when the sample was taken.
+	Lap  uint16
+	Wear [4]float32
+}
+
+// Tracker records tire wear at every lap boundary of the current stint.
+// A stint ends when a new set of tires is fitted: every tire loses more than
+// newTireDrop of wear, or drops to freshWear. A rewind, seen as the lap or the
+// race time going backwards, drops the lap boundaries after it, unless the
+// tires are fresh, as after a restart.
+type Tracker struct {
+	stint   []LapWear
+	last    fmtel.ForzaPacket
+	hasLast bool
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Feeds a packet to the tracker. Only packets sent while a race is on should be passed in.
+func (t *Tracker) Update(p *fmtel.ForzaPacket) {
+	wear := Wear(p)
+	if !t.hasLast {
+		t.stint = []LapWear{{Lap: p.LapNumber, Wear: wear}}
+		t.last = *p
+		t.hasLast = true
+		return
+	}
+
+	prev := Wear(&t.last)
+	rewound := p.LapNumber < t.last.LapNumber || p.CurrentRaceTime < t.last.CurrentRaceTime
+	t.last = *p
+	if rewound && !fresh(wear) {
+		t.rewind(p.LapNumber, wear)
+		return
+	}
+	if rewound || newTires(prev, wear) {
+		t.stint = []LapWear{{Lap: p.LapNumber, Wear: wear}}
+		return
+	}
+	if p.LapNumber > t.stint[len(t.stint)-1].Lap {
+		t.stint = append(t.stint, LapWear{Lap: p.LapNumber, Wear: prev})
+	}
+}
+
+// Drops the lap boundaries after lap. Starts over from wear if none are left.
+func (t *Tracker) rewind(lap uint16, wear [4]float32) {
+	n := len(t.stint)
+	for n > 0 && t.stint[n-1].Lap > lap {
+		n--
+	}
+	t.stint = t.stint[:n]
+	if n == 0 {
+		t.stint = []LapWear{{Lap: lap, Wear: wear}}
+	}
+}
+
+// Returns true if every tire is at or below freshWear.
+func fresh(wear [4]float32) bool {
+	for _, w := range wear {
+		if w > freshWear {
+			return false
+		}
+	}
+	return true
+}
+
+func newTires(prev, wear [4]float32) bool {
+	for i := range wear {
+		if prev[i]-wear[i] <= newTireDrop && (wear[i] >= prev[i] || wear[i] > freshWear) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the wear at every lap boundary of the current stint, oldest first.
+func (t *Tracker) Stint() []LapWear {
+	return t.stint
+}
+
+// Forgets the current stint.
+func (t *Tracker) Reset() {
+	t.stint = nil
+	t.hasLast = false
+}
+
+// Fit is a linear degradation model per corner: wear = Offset + Rate * lap.
+type Fit struct {
+	// Wear per lap.
+	Rate   [4]float32
+	Offset [4]float32
+	// Number of lap boundaries the fit is based on.
+	Laps int
+}
+
+// Fits the wear of the current stint with least squares.
+// Returns false until two lap boundaries have been recorded.
+func (t *Tracker) Fit() (Fit, bool) {
+	n := len(t.stint)
+	if n < 2 {
+		return Fit{}, false
+	}
+
+	var sx, sxx float64
+	for _, s := range t.stint {
+		x := float64(s.Lap)
+		sx += x
+		sxx += x * x
+	}
+	det := float64(n)*sxx - sx*sx
+	if det == 0 {
+		return Fit{}, false
+	}
+
+	f := Fit{Laps: n}
+	for i := range Corners {
+		var sy, sxy float64
+		for _, s := range t.stint {
+			sy += float64(s.Wear[i])
+			sxy += float64(s.Lap) * float64(s.Wear[i])
+		}
+		rate := (float64(n)*sxy - sx*sy) / det
+		f.Rate[i] = float32(rate)
+		f.Offset[i] = float32((sy - rate*sx) / float64(n))
+	}
+	return f, true
+}
+
+// Returns the predicted wear of a corner after the given number of laps.
+func (f Fit) At(c Corner, lap float32) float32 {
+	return f.Offset[c] + f.Rate[c]*lap
+}
+
+// Prediction is the first tire expected to reach the wear limit.
+type Prediction struct {
+	Corner Corner
+	// Laps completed when the limit is reached.
+	Lap float32
+}
+
+// Returns the first corner to reach the wear limit and when.
+// Returns false if no tire is wearing.
+func (f Fit) Predict(limit float32) (Prediction, bool) {
+	best := Prediction{Lap: float32(math.Inf(1))}
+	for _, c := range Corners {
+		if f.Rate[c] <= 0 {
+			continue
+		}
+		lap := (limit - f.Offset[c]) / f.Rate[c]
+		if lap < best.Lap {
+			best = Prediction{Corner: c, Lap: lap}
+		}
+	}
+	if math.IsInf(float64(best.Lap), 1) {
+		return Prediction{}, false
+	}
+	return best, true
+}
@@ -0,0 +1,95 @@
+package tires
+
+import (
+	"testing"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+func wearPacket(lap uint16, raceTime float32, wear [4]float32) *fmtel.ForzaPacket {
+	return &fmtel.ForzaPacket{
+		LapNumber:          lap,
+		CurrentRaceTime:    raceTime,
+		TireWearFrontLeft:  wear[0],
+		TireWearFrontRight: wear[1],
+		TireWearRearLeft:   wear[2],
+		TireWearRearRight:  wear[3],
+	}
+}
+
+func all(w float32) [4]float32 {
+	return [4]float32{w, w, w, w}
+}
+
+// Returns a tracker with a stint of four laps, ending at lap 4 with the given wear.
+func fourLaps(end float32) *Tracker {
+	tr := NewTracker()
+	for lap := uint16(0); lap <= 4; lap++ {
+		tr.Update(wearPacket(lap, float32(lap)*60, all(end*float32(lap)/4)))
+	}
+	return tr
+}
+
+func TestStintIgnoresSmallWearDrops(t *testing.T) {
+	tr := NewTracker()
+	tr.Update(wearPacket(0, 1, all(0.10)))
+	tr.Update(wearPacket(1, 2, all(0.20)))
+	// One tire reads a little lower, as the wear value jitters.
+	tr.Update(wearPacket(1, 3, [4]float32{0.18, 0.21, 0.21, 0.21}))
+	tr.Update(wearPacket(2, 4, all(0.30)))
+	if got := len(tr.Stint()); got != 3 {
+		t.Fatalf("stint has %d lap boundaries, want 3", got)
+	}
+}
+
+func TestStintNewTires(t *testing.T) {
+	tests := []struct {
+		name  string
+		end   float32
+		wear  [4]float32
+		reset bool
+	}{
+		{"new set", 0.30, all(0.01), true},
+		{"fresh set after a short stint", 0.04, all(0), true},
+		{"jitter on all four", 0.15, all(0.1499), false},
+		{"small drop on all four", 0.30, all(0.28), false},
+		{"one tire", 0.30, [4]float32{0.30, 0.30, 0.30, 0.01}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := fourLaps(tt.end)
+			tr.Update(wearPacket(4, 250, tt.wear))
+			stint := tr.Stint()
+			if tt.reset && (len(stint) != 1 || stint[0].Wear != tt.wear) {
+				t.Fatalf("got stint %v, want a new stint from %v", stint, tt.wear)
+			}
+			if !tt.reset && len(stint) != 5 {
+				t.Fatalf("stint has %d lap boundaries, want the 5 kept", len(stint))
+			}
+		})
+	}
+}
+
+func TestStintRewind(t *testing.T) {
+	tr := fourLaps(0.20)
+	// Rewind back into lap 2, where the wear was lower.
+	tr.Update(wearPacket(2, 150, all(0.12)))
+	stint := tr.Stint()
+	if len(stint) != 3 || stint[len(stint)-1].Lap != 2 {
+		t.Fatalf("got stint %v, want the boundaries up to lap 2", stint)
+	}
+
+	tr.Update(wearPacket(3, 185, all(0.15)))
+	if got := len(tr.Stint()); got != 4 {
+		t.Fatalf("stint has %d lap boundaries after driving on, want 4", got)
+	}
+}
+
+func TestStintRestart(t *testing.T) {
+	tr := fourLaps(0.20)
+	tr.Update(wearPacket(0, 0.5, all(0)))
+	stint := tr.Stint()
+	if len(stint) != 1 || stint[0].Wear != all(0) {
+		t.Fatalf("got stint %v, want a new stint after the restart", stint)
+	}
+}
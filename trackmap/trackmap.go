@@ -0,0 +1,184 @@
+package trackmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Minimum distance in meters between two recorded points.
+const pointSpacing = 2.0
+
+// A move longer than this in meters between two packets is a teleport, such as a rewind or reset.
+const maxJump = 50.0
+
+// A lap with fewer points than this is not a usable outline.
+const minPoints = 50
+
+// Point is a position on the ground plane in world coordinates.
+type Point struct {
+	X float32 `json:"x"`
+	Z float32 `json:"z"`
+}
+
+// Returns the position of the car in the packet.
+func Position(p *fmtel.ForzaPacket) Point {
+	return Point{X: p.PositionX, Z: p.PositionZ}
+}
+
+func (a Point) Distance(b Point) float32 {
+	return float32(math.Hypot(float64(a.X-b.X), float64(a.Z-b.Z)))
+}
+
+// Map is the learned outline of a track.
+type Map struct {
+	TrackOrdinal int32 `json:"track_ordinal"`
+	// Outline of the circuit, starting at the start/finish line.
+	Outline []Point `json:"outline"`
+}
+
+// Returns the corners of the box around the outline.
+func (m *Map) Bounds() (min, max Point) {
+	if len(m.Outline) == 0 {
+		return
+	}
+	min, max = m.Outline[0], m.Outline[0]
+	for _, p := range m.Outline[1:] {
+		min.X = float32(math.Min(float64(min.X), float64(p.X)))
+		min.Z = float32(math.Min(float64(min.Z), float64(p.Z)))
+		max.X = float32(math.Max(float64(max.X), float64(p.X)))
+		max.Z = float32(math.Max(float64(max.Z), float64(p.Z)))
+	}
+	return min, max
+}
+
+// Returns the start/finish line position.
+func (m *Map) Start() (Point, bool) {
+	if len(m.Outline) == 0 {
+		return Point{}, false
+	}
+	return m.Outline[0], true
+}
+
+// Tracker learns the track outline from the first clean lap and keeps the
+// trace of the fastest lap. A lap is clean if it was started by crossing the
+// line and had no rewind, restart or teleport.
+type Tracker struct {
+	outline    Map
+	hasOutline bool
+
+	best     []Point
+	bestTime float32
+
+	current []Point
+	clean   bool
+	last    fmtel.ForzaPacket
+	hasLast bool
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Uses a previously learned map instead of waiting for a clean lap.
+func (t *Tracker) SetMap(m Map) {
+	t.outline = m
+	t.hasOutline = len(m.Outline) > 0
+}
+
+// Returns the outline of the current track.
+func (t *Tracker) Map() (Map, bool) {
+	return t.outline, t.hasOutline
+}
+
+// Returns the trace of the fastest clean lap, or nil.
+func (t *Tracker) Best() []Point {
+	return t.best
+}
+
+// Forgets the outline, the best lap and the lap in progress.
+func (t *Tracker) Reset() {
+	*t = Tracker{}
+}
+
+// Feeds a packet to the tracker. Returns true if this packet completed the
+// lap the outline was learned from. Only packets sent while a race is on should be passed in.
+func (t *Tracker) Update(p *fmtel.ForzaPacket) bool {
+	pos := Position(p)
+	if !t.hasLast || p.TrackOrdinal != t.last.TrackOrdinal {
+		// Keep a map set for this track with SetMap.
+		outline := t.outline
+		t.Reset()
+		t.outline.TrackOrdinal = p.TrackOrdinal
+		if outline.TrackOrdinal == p.TrackOrdinal {
+			t.SetMap(outline)
+		}
+		t.current = []Point{pos}
+		t.last = *p
+		t.hasLast = true
+		return false
+	}
+	prev := t.last
+	t.last = *p
+
+	if p.LapNumber < prev.LapNumber || p.CurrentRaceTime < prev.CurrentRaceTime ||
+		(p.LapNumber == prev.LapNumber && p.CurrentLap < prev.CurrentLap) ||
+		pos.Distance(Position(&prev)) > maxJump {
+		t.clean = false
+	}
+
+	if p.LapNumber > prev.LapNumber {
+		learned := false
+		if t.clean && len(t.current) >= minPoints {
+			if !t.hasOutline {
+				t.outline.Outline = t.current
+				t.hasOutline = true
+				learned = true
+			}
+			if t.best == nil || (p.LastLap > 0 && p.LastLap < t.bestTime) {
+				t.best = t.current
+				t.bestTime = p.LastLap
+			}
+		}
+		t.current = []Point{pos}
+		t.clean = true
+		return learned
+	}
+
+	if pos.Distance(t.current[len(t.current)-1]) >= pointSpacing {
+		t.current = append(t.current, pos)
+	}
+	return false
+}
+
+func path(dir string, ordinal int32) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.json", ordinal))
+}
+
+// Loads the map saved for a track from dir. Returns an error wrapping
+// fs.ErrNotExist if there is none.
+func Load(dir string, ordinal int32) (Map, error) {
+	var m Map
+	content, err := os.ReadFile(path(dir, ordinal))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(content, &m)
+	return m, err
+}
+
+// Saves a map to dir, creating it if needed.
+func Save(dir string, m Map) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path(dir, m.TrackOrdinal), data, 0o644)
+}
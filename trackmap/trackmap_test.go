@@ -0,0 +1,131 @@
+package trackmap
+
+import (
+	"errors"
+	"io/fs"
+	"math"
+	"testing"
+
+	"github.com/stelmanjones/fmtel"
+)
+
+// Steps per lap of a circle with a radius of 100m, about a meter each.
+const lapSteps = 600
+
+// Returns the packets of a lap of the circle, starting with the packet that crosses onto lap.
+func circleLap(track int32, lap uint16, lastLap float32) []fmtel.ForzaPacket {
+	var ps []fmtel.ForzaPacket
+	for i := 0; i < lapSteps; i++ {
+		a := 2 * math.Pi * float64(i) / lapSteps
+		p := fmtel.ForzaPacket{
+			TrackOrdinal:    track,
+			LapNumber:       lap,
+			CurrentLap:      float32(i) / 10,
+			CurrentRaceTime: float32(int(lap)*lapSteps+i) / 10,
+			PositionX:       float32(100 * math.Cos(a)),
+			PositionZ:       float32(100 * math.Sin(a)),
+		}
+		if i == 0 {
+			p.LastLap = lastLap
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// Feeds packets and returns true if one of them completed the lap the outline was learned from.
+func feed(tr *Tracker, ps []fmtel.ForzaPacket) bool {
+	learned := false
+	for i := range ps {
+		if tr.Update(&ps[i]) {
+			learned = true
+		}
+	}
+	return learned
+}
+
+func TestLearnOutline(t *testing.T) {
+	tr := NewTracker()
+	// Lap 0 was joined in progress, so it is not clean.
+	if feed(tr, circleLap(7, 0, 0)) {
+		t.Fatal("learned the outline from the lap the tracker joined")
+	}
+	if feed(tr, circleLap(7, 1, 60)) {
+		t.Fatal("learned the outline before completing a clean lap")
+	}
+	if !feed(tr, circleLap(7, 2, 59)) {
+		t.Fatal("did not learn the outline from a clean lap")
+	}
+
+	m, ok := tr.Map()
+	if !ok || m.TrackOrdinal != 7 {
+		t.Fatalf("got map of track %d, %v", m.TrackOrdinal, ok)
+	}
+	// Points 2m apart around a circle of about 628m.
+	if n := len(m.Outline); n < 300 || n > 320 {
+		t.Errorf("got %d points, want about 314", n)
+	}
+	if start, _ := m.Start(); start.Distance(Point{X: 100}) > 1e-3 {
+		t.Errorf("outline starts at %v, want the line", start)
+	}
+	min, max := m.Bounds()
+	if min.X > -99 || min.Z > -99 || max.X < 99 || max.Z < 99 {
+		t.Errorf("bounds %v %v, want the circle", min, max)
+	}
+	if len(tr.Best()) != len(m.Outline) {
+		t.Error("the clean lap is not the best lap")
+	}
+
+	// A later lap does not change the outline.
+	if feed(tr, circleLap(7, 3, 58)) {
+		t.Fatal("learned the outline twice")
+	}
+}
+
+func TestTeleportIsNotClean(t *testing.T) {
+	tr := NewTracker()
+	feed(tr, circleLap(7, 0, 0))
+	lap := circleLap(7, 1, 60)
+	for i := 300; i < 310; i++ {
+		lap[i].PositionX += 500
+	}
+	feed(tr, lap)
+	if feed(tr, circleLap(7, 2, 59)) {
+		t.Fatal("learned the outline from a lap with a teleport")
+	}
+}
+
+func TestTrackChange(t *testing.T) {
+	tr := NewTracker()
+	tr.SetMap(Map{TrackOrdinal: 7, Outline: []Point{{X: 1}}})
+	p := fmtel.ForzaPacket{TrackOrdinal: 7}
+	tr.Update(&p)
+	if _, ok := tr.Map(); !ok {
+		t.Fatal("dropped the map set for the current track")
+	}
+
+	p.TrackOrdinal = 8
+	tr.Update(&p)
+	m, ok := tr.Map()
+	if ok || m.TrackOrdinal != 8 {
+		t.Fatalf("got map of track %d, %v after a track change, want none for 8", m.TrackOrdinal, ok)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir, 7); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got %v for a missing map, want fs.ErrNotExist", err)
+	}
+	want := Map{TrackOrdinal: 7, Outline: []Point{{X: 1, Z: 2}, {X: 3, Z: 4}}}
+	if err := Save(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TrackOrdinal != 7 || len(got.Outline) != 2 || got.Outline[1] != want.Outline[1] {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package tracks
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+//go:embed tracks.json
+var embedded []byte
+
+var DefaultTrack = Track{
+	Circuit: "Unknown",
+	Layout:  "Unknown",
+	Country: "Unknown",
+}
+
+type Track struct {
+	TrackOrdinal int32  `json:"track_ordinal"`
+	Circuit      string `json:"circuit"`
+	Layout       string `json:"layout"`
+	Country      string `json:"country"`
+	// Lap length in meters.
+	Length float32 `json:"length"`
+	// Distances from the start line in meters at which each sector after the first begins.
+	Sectors []float32 `json:"sectors"`
+}
+
+// Returns the circuit and layout as a single label.
+func (t *Track) Name() string {
+	if t.Layout == "" {
+		return t.Circuit
+	}
+	return fmt.Sprintf("%s - %s", t.Circuit, t.Layout)
+}
+
+// Returns the zero-based sector containing the given distance into the lap.
+func (t *Track) Sector(distance float32) int {
+	for i, split := range t.Sectors {
+		if distance < split {
+			return i
+		}
+	}
+	return len(t.Sectors)
+}
+
+// Database holds known tracks indexed by ordinal.
+type Database struct {
+	tracks map[int32]Track
+}
+
+// Loads the embedded track list and merges the user file at path over it.
+// A missing user file is not an error.
+func Load(path string) (*Database, error) {
+	db := &Database{tracks: make(map[int32]Track)}
+	err := db.merge(embedded)
+	if err != nil {
+		return nil, fmt.Errorf("embedded tracks: %w", err)
+	}
+	if path == "" {
+		return db, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return db, err
+	}
+	err = db.merge(content)
+	if err != nil {
+		return db, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Adds the tracks in a JSON list, replacing any with the same ordinal.
+func (d *Database) merge(content []byte) error {
+	var list []Track
+	err := json.Unmarshal(content, &list)
+	if err != nil {
+		return err
+	}
+	for _, t := range list {
+		d.tracks[t.TrackOrdinal] = t
+	}
+	return nil
+}
+
+func (d *Database) Find(ordinal int32) (Track, bool) {
+	t, ok := d.tracks[ordinal]
+	return t, ok
+}
+
+// Returns the track with the given ordinal, or DefaultTrack carrying the ordinal.
+func (d *Database) Get(ordinal int32) Track {
+	t, ok := d.tracks[ordinal]
+	if !ok {
+		t = DefaultTrack
+		t.TrackOrdinal = ordinal
+	}
+	return t
+}
+
+func (d *Database) Len() int {
+	return len(d.tracks)
+}
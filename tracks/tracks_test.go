@@ -0,0 +1,97 @@
+package tracks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddedList(t *testing.T) {
+	var list []Track
+	if err := json.Unmarshal(embedded, &list); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int32]bool)
+	for _, track := range list {
+		if seen[track.TrackOrdinal] {
+			t.Errorf("track ordinal %d listed twice", track.TrackOrdinal)
+		}
+		seen[track.TrackOrdinal] = true
+		if track.Circuit == "" {
+			t.Errorf("track ordinal %d has no circuit", track.TrackOrdinal)
+		}
+	}
+}
+
+func TestLoadMergesUserFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracks.json")
+	user := `[
+		{"track_ordinal": 9000, "circuit": "Test Circuit", "layout": "Full", "length": 4000, "sectors": [1300, 2700]},
+		{"track_ordinal": 9001, "circuit": "Test Oval"}
+	]`
+	if err := os.WriteFile(path, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	embeddedOnly, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != embeddedOnly.Len()+2 {
+		t.Fatalf("got %d tracks, want %d", db.Len(), embeddedOnly.Len()+2)
+	}
+
+	track, ok := db.Find(9000)
+	if !ok || track.Name() != "Test Circuit - Full" || track.Length != 4000 {
+		t.Fatalf("got %+v", track)
+	}
+	oval := db.Get(9001)
+	if got := oval.Name(); got != "Test Oval" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestLoadMissingUserFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadInvalidUserFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracks.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(path)
+	if err == nil {
+		t.Fatal("invalid user file loaded")
+	}
+	if db == nil {
+		t.Fatal("embedded tracks lost with an invalid user file")
+	}
+}
+
+func TestGetUnknown(t *testing.T) {
+	db, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := db.Get(-1)
+	if track.TrackOrdinal != -1 || track.Circuit != DefaultTrack.Circuit {
+		t.Fatalf("got %+v", track)
+	}
+}
+
+func TestSector(t *testing.T) {
+	track := Track{Sectors: []float32{1300, 2700}}
+	for distance, want := range map[float32]int{0: 0, 1299: 0, 1300: 1, 2699: 1, 2700: 2, 4000: 2} {
+		if got := track.Sector(distance); got != want {
+			t.Errorf("Sector(%v) = %d, want %d", distance, got, want)
+		}
+	}
+}